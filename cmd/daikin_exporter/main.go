@@ -1,20 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"io"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 
 	"github.com/int2xx9/daikin-airconditioner/daikin"
 	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/mra"
+	"github.com/int2xx9/daikin-airconditioner/smartmeter"
+	"github.com/int2xx9/daikin-airconditioner/solar"
+	"github.com/int2xx9/daikin-airconditioner/storagebattery"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/exp/slog"
 )
 
 var (
-	optionPort = flag.Int("port", 2112, "port number")
+	optionPort    = flag.Int("port", 2112, "port number")
+	optionTargets = flag.String("targets", "", "comma-separated device addresses to query (default: multicast to all devices)")
+	optionIface   = flag.String("interface", "", "network interface to communicate with devices on (default: system default)")
+	optionLocal   = flag.String("local-address", "", "local address whose interface is used to communicate with devices; the socket is bound to the wildcard address with its port (default: 0.0.0.0:3610)")
+	optionNetwork = flag.String("network", "udp4", "network to communicate with devices on (udp4, udp6 or udp for dual stack)")
+	optionCapture = flag.String("capture", "", "file to record ECHONET Lite traffic to (pcap if it ends with .pcap, pcapng otherwise)")
+	optionTrace   = flag.Bool("trace", false, "log every ECHONET Lite frame sent and received")
+	optionMRA     = flag.String("mra", "", "mraData directory of the Machine Readable Appendix to decode traced properties with (default: embedded subset)")
+	optionMeters  = flag.String("smart-meters", "", "comma-separated smart electric energy meter addresses to query (default: none)")
+	optionSolars  = flag.String("solar-inverters", "", "comma-separated solar power generation (inverter) addresses to query (default: none)")
+	optionBattery = flag.String("storage-batteries", "", "comma-separated storage battery addresses to query (default: none)")
+	optionRetries = flag.Int("retries", echonetlite.DefaultRetryPolicy.Count, "number of retries when a device doesn't respond")
 )
 
 func main() {
@@ -23,20 +44,124 @@ func main() {
 
 	flag.Parse()
 
+	targets, err := parseTargets(*optionTargets)
+	if err != nil {
+		slog.Error("invalid targets", "error", err)
+		os.Exit(1)
+	}
+
+	smartMeters, err := parseTargets(*optionMeters)
+	if err != nil {
+		slog.Error("invalid smart meters", "error", err)
+		os.Exit(1)
+	}
+
+	solars, err := parseTargets(*optionSolars)
+	if err != nil {
+		slog.Error("invalid solar power generations", "error", err)
+		os.Exit(1)
+	}
+	storageBatteries, err := parseTargets(*optionBattery)
+	if err != nil {
+		slog.Error("invalid storage batteries", "error", err)
+		os.Exit(1)
+	}
+
 	handler := newDaikinPrometheusHandler()
+	handler.targets = targets
+	handler.smartMeters = smartMeters
+	handler.solars = solars
+	handler.storageBatteries = storageBatteries
+	handler.retry = echonetlite.DefaultRetryPolicy
+	handler.retry.Count = *optionRetries
 	handler.controller.Logger = logger
-	handler.controller.Start()
+	if *optionIface != "" {
+		ifi, err := net.InterfaceByName(*optionIface)
+		if err != nil {
+			slog.Error("invalid interface", "error", err)
+			os.Exit(1)
+		}
+		handler.controller.Interface = ifi
+		for _, addrs := range [][]net.UDPAddr{handler.targets, handler.smartMeters, handler.solars, handler.storageBatteries} {
+			setDefaultZone(addrs, ifi.Name)
+		}
+	}
+	if *optionLocal != "" {
+		laddr, err := parseAddress(*optionLocal)
+		if err != nil {
+			slog.Error("invalid local address", "error", err)
+			os.Exit(1)
+		}
+		handler.controller.LocalAddress = laddr
+	}
+	handler.controller.Network = *optionNetwork
+	handler.controller.Trace = *optionTrace
+	if *optionTrace {
+		db := mra.Default()
+		if *optionMRA != "" {
+			if db, err = mra.LoadDir(*optionMRA); err != nil {
+				slog.Error("failed to load the MRA", "error", err)
+				os.Exit(1)
+			}
+		}
+		db.Register()
+	}
+	var captureFile io.Closer
+	if *optionCapture != "" {
+		capture, f, err := createCapture(*optionCapture)
+		if err != nil {
+			slog.Error("failed to create a capture file", "error", err)
+			os.Exit(1)
+		}
+		handler.controller.Capture = capture
+		captureFile = f
+	}
+	if err := handler.controller.Start(); err != nil {
+		slog.Error("failed to start a controller", "error", err)
+		if captureFile != nil {
+			captureFile.Close()
+		}
+		os.Exit(1)
+	}
 
+	// stop on SIGINT or SIGTERM so that the capture file is closed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	http.Handle("/metrics", handler)
-	http.ListenAndServe(":"+strconv.Itoa(*optionPort), nil)
+	server := &http.Server{Addr: ":" + strconv.Itoa(*optionPort)}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("failed to serve", "error", err)
+	}
+
+	handler.controller.Stop()
+	if captureFile != nil {
+		if err := captureFile.Close(); err != nil {
+			slog.Error("failed to close the capture file", "error", err)
+		}
+	}
 }
 
 type daikinPrometheusHandler struct {
-	registry          *prometheus.Registry
-	prometheusHandler http.Handler
-	metrics           daikinMetrics
-	controller        echonetlite.Controller
-	daikin            daikin.Daikin
+	registry              *prometheus.Registry
+	prometheusHandler     http.Handler
+	metrics               daikinMetrics
+	controller            echonetlite.Controller
+	daikin                daikin.Daikin
+	targets               []net.UDPAddr
+	smartMeterMetrics     smartMeterMetrics
+	smartMeter            smartmeter.SmartMeter
+	smartMeters           []net.UDPAddr
+	solarMetrics          solarMetrics
+	solar                 solar.Solar
+	solars                []net.UDPAddr
+	storageBatteryMetrics storageBatteryMetrics
+	storageBattery        storagebattery.StorageBattery
+	storageBatteries      []net.UDPAddr
+	retry                 echonetlite.RetryPolicy
 }
 
 func newDaikinPrometheusHandler() *daikinPrometheusHandler {
@@ -47,6 +172,12 @@ func newDaikinPrometheusHandler() *daikinPrometheusHandler {
 	handler.metrics = newDaikinMetrics(handler.registry)
 	handler.prometheusHandler = promhttp.HandlerFor(handler.registry, promhttp.HandlerOpts{Registry: handler.registry})
 	handler.daikin = daikin.NewDaikin(&handler.controller)
+	handler.smartMeterMetrics = newSmartMeterMetrics(handler.registry)
+	handler.smartMeter = smartmeter.NewSmartMeter(&handler.controller)
+	handler.solarMetrics = newSolarMetrics(handler.registry)
+	handler.solar = solar.NewSolar(&handler.controller)
+	handler.storageBatteryMetrics = newStorageBatteryMetrics(handler.registry)
+	handler.storageBattery = storagebattery.NewStorageBattery(&handler.controller)
 	return handler
 }
 
@@ -54,8 +185,8 @@ func (handler *daikinPrometheusHandler) Close() error {
 	return handler.controller.Close()
 }
 
-func (handler *daikinPrometheusHandler) updateMetrics() error {
-	resps, err := handler.daikin.Request().
+func (handler *daikinPrometheusHandler) newRequest() daikin.QueryRequest {
+	return handler.daikin.Request().
 		IdentificationNumber().
 		OperationStatus().
 		InstantaneousPowerConsumption().
@@ -68,7 +199,28 @@ func (handler *daikinPrometheusHandler) updateMetrics() error {
 		RoomTemperature().
 		RoomHumidity().
 		OutdoorTemperature().
-		Query()
+		SetRetry(handler.retry)
+}
+
+func (handler *daikinPrometheusHandler) query(ctx context.Context) ([]daikin.QueryResponse, error) {
+	if len(handler.targets) == 0 {
+		return handler.newRequest().QueryContext(ctx)
+	}
+
+	resps := []daikin.QueryResponse{}
+	for _, target := range handler.targets {
+		r, err := handler.newRequest().SetAddress(target).QueryContext(ctx)
+		if err != nil {
+			slog.Info("[query] query failed", "address", target.String(), "error", err)
+			continue
+		}
+		resps = append(resps, r...)
+	}
+	return resps, nil
+}
+
+func (handler *daikinPrometheusHandler) updateMetrics(ctx context.Context) error {
+	resps, err := handler.query(ctx)
 	if err != nil {
 		return err
 	}
@@ -85,14 +237,19 @@ func (handler *daikinPrometheusHandler) updateMetrics() error {
 	handler.metrics.roomHumidity.Reset()
 	handler.metrics.roomTemperature.Reset()
 	handler.metrics.outdoorTemperature.Reset()
+	handler.metrics.supportedProperty.Reset()
 
 	slog.Debug("[updateMetrics] responses retrieved", "device_count", len(resps))
 	for _, resp := range resps {
 		id, err := resp.IdentificationNumber()
 		if err != nil {
-			return err
+			slog.Info("[updateMetrics] no identification number", "address", resp.Address.String(), "error", err)
+			continue
 		}
 		idstr := bytesToString(id)
+		if resp.Partial {
+			slog.Debug("[updateMetrics] partial response", "id", idstr, "errors", resp.Errors())
+		}
 
 		if err := updateBoolMetrics(resp.Address, idstr, resp.OperationStatus, handler.metrics.operationStatus); err != nil {
 			slog.Info("[updateMetrics] update failed", "id", idstr, "property", "OperationStatus", "error", err)
@@ -137,6 +294,12 @@ func (handler *daikinPrometheusHandler) updateMetrics() error {
 		if err := updateNumberMetrics(resp.Address, idstr, resp.OutdoorTemperature, handler.metrics.outdoorTemperature); err != nil {
 			slog.Info("[updateMetrics] update failed", "id", idstr, "property", "OutdoorTemperature", "error", err)
 		}
+
+		if caps, err := handler.daikin.Capabilities(ctx, resp.Address, resp.Object); err != nil {
+			slog.Info("[updateMetrics] update failed", "id", idstr, "property", "PropertyMap", "error", err)
+		} else {
+			updateSupportedPropertyMetrics(resp.Address, idstr, caps, handler.metrics.supportedProperty)
+		}
 	}
 	return nil
 }
@@ -154,6 +317,7 @@ type daikinMetrics struct {
 	roomTemperature               *prometheus.GaugeVec
 	roomHumidity                  *prometheus.GaugeVec
 	outdoorTemperature            *prometheus.GaugeVec
+	supportedProperty             *prometheus.GaugeVec
 }
 
 func newDaikinMetrics(reg prometheus.Registerer) daikinMetrics {
@@ -208,6 +372,10 @@ func newDaikinMetrics(reg prometheus.Registerer) daikinMetrics {
 			prometheus.GaugeOpts{Name: "outdoor_temperature", Help: "outdoor temperature (-127 to 125 degree(s) Celsius)"},
 			commonLabels,
 		),
+		supportedProperty: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "supported_property", Help: "a property in the property maps (always 1)"},
+			append(commonLabels, "epc", "access"),
+		),
 	}
 
 	reg.MustRegister(metrics.operationStatus)
@@ -222,11 +390,15 @@ func newDaikinMetrics(reg prometheus.Registerer) daikinMetrics {
 	reg.MustRegister(metrics.roomHumidity)
 	reg.MustRegister(metrics.roomTemperature)
 	reg.MustRegister(metrics.outdoorTemperature)
+	reg.MustRegister(metrics.supportedProperty)
 
 	return metrics
 }
 
 func (handler *daikinPrometheusHandler) ServeHTTP(response http.ResponseWriter, req *http.Request) {
-	handler.updateMetrics()
+	handler.updateMetrics(req.Context())
+	handler.updateSmartMeterMetrics(req.Context())
+	handler.updateSolarMetrics(req.Context())
+	handler.updateStorageBatteryMetrics(req.Context())
 	handler.prometheusHandler.ServeHTTP(response, req)
 }
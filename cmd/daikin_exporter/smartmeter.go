@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/int2xx9/daikin-airconditioner/smartmeter"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/slog"
+)
+
+type smartMeterMetrics struct {
+	cumulativeEnergy          *prometheus.GaugeVec
+	fixedTimeCumulativeEnergy *prometheus.GaugeVec
+	historicalEnergy          *prometheus.GaugeVec
+	instantaneousPower        *prometheus.GaugeVec
+	instantaneousCurrent      *prometheus.GaugeVec
+}
+
+func newSmartMeterMetrics(reg prometheus.Registerer) smartMeterMetrics {
+	commonLabels := []string{"address", "id"}
+
+	metrics := smartMeterMetrics{
+		cumulativeEnergy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "smart_meter_cumulative_energy", Help: "cumulative energy bought from (direction=normal) or sold to (direction=reverse) the grid (unit:kWh)"},
+			append(commonLabels, "direction"),
+		),
+		fixedTimeCumulativeEnergy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "smart_meter_fixed_time_cumulative_energy", Help: "cumulative energy bought from the grid at the last 30 minutes boundary (unit:kWh)"},
+			commonLabels,
+		),
+		historicalEnergy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "smart_meter_historical_cumulative_energy", Help: "cumulative energy bought from (direction=normal) or sold to (direction=reverse) the grid at every 30 minutes of a past day (unit:kWh)"},
+			append(commonLabels, "direction", "day", "time"),
+		),
+		instantaneousPower: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "smart_meter_instantaneous_power", Help: "instantaneous power, negative while selling to the grid (unit:W)"},
+			commonLabels,
+		),
+		instantaneousCurrent: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "smart_meter_instantaneous_current", Help: "instantaneous current of the R or T phase (unit:A)"},
+			append(commonLabels, "phase"),
+		),
+	}
+
+	reg.MustRegister(metrics.cumulativeEnergy)
+	reg.MustRegister(metrics.fixedTimeCumulativeEnergy)
+	reg.MustRegister(metrics.historicalEnergy)
+	reg.MustRegister(metrics.instantaneousPower)
+	reg.MustRegister(metrics.instantaneousCurrent)
+
+	return metrics
+}
+
+func (handler *daikinPrometheusHandler) newSmartMeterRequest() smartmeter.QueryRequest {
+	return handler.smartMeter.Request().
+		IdentificationNumber().
+		CumulativeEnergy().
+		ReverseCumulativeEnergy().
+		FixedTimeCumulativeEnergy().
+		HistoricalCumulativeEnergy().
+		ReverseHistoricalCumulativeEnergy().
+		InstantaneousElectricPower().
+		InstantaneousCurrents().
+		SetRetry(handler.retry)
+}
+
+func (handler *daikinPrometheusHandler) updateSmartMeterMetrics(ctx context.Context) {
+	metrics := handler.smartMeterMetrics
+	metrics.cumulativeEnergy.Reset()
+	metrics.fixedTimeCumulativeEnergy.Reset()
+	metrics.historicalEnergy.Reset()
+	metrics.instantaneousPower.Reset()
+	metrics.instantaneousCurrent.Reset()
+
+	for _, target := range handler.smartMeters {
+		resps, err := handler.newSmartMeterRequest().SetAddress(target).QueryContext(ctx)
+		if err != nil {
+			slog.Info("[updateSmartMeterMetrics] query failed", "address", target.String(), "error", err)
+			continue
+		}
+		for _, resp := range resps {
+			updateSmartMeterMetrics(resp, metrics)
+		}
+	}
+}
+
+func updateSmartMeterMetrics(resp smartmeter.QueryResponse, metrics smartMeterMetrics) {
+	// the identification number is optional for meters
+	idstr := ""
+	if id, err := resp.IdentificationNumber(); err == nil {
+		idstr = bytesToString(id)
+	}
+	labels := func(extra ...string) []string {
+		return append([]string{resp.Address.String(), idstr}, extra...)
+	}
+	logFailure := func(property string, err error) {
+		slog.Info("[updateSmartMeterMetrics] update failed", "address", resp.Address.String(), "id", idstr, "property", property, "error", err)
+	}
+
+	if value, err := resp.CumulativeEnergy(); err != nil {
+		logFailure("CumulativeEnergy", err)
+	} else {
+		metrics.cumulativeEnergy.WithLabelValues(labels("normal")...).Set(value)
+	}
+	if value, err := resp.ReverseCumulativeEnergy(); err != nil {
+		logFailure("ReverseCumulativeEnergy", err)
+	} else {
+		metrics.cumulativeEnergy.WithLabelValues(labels("reverse")...).Set(value)
+	}
+	if _, value, err := resp.FixedTimeCumulativeEnergy(); err != nil {
+		logFailure("FixedTimeCumulativeEnergy", err)
+	} else {
+		metrics.fixedTimeCumulativeEnergy.WithLabelValues(labels()...).Set(value)
+	}
+	setHistorical := func(direction string, h smartmeter.HistoricalEnergy) {
+		day := fmt.Sprint(h.Day)
+		for i, value := range h.Values {
+			// slots which the meter has no data for are left out
+			if math.IsNaN(value) {
+				continue
+			}
+			at := fmt.Sprintf("%02d:%02d", i/2, i%2*30)
+			metrics.historicalEnergy.WithLabelValues(labels(direction, day, at)...).Set(value)
+		}
+	}
+	if h, err := resp.HistoricalCumulativeEnergy(); err != nil {
+		logFailure("HistoricalCumulativeEnergy", err)
+	} else {
+		setHistorical("normal", h)
+	}
+	if h, err := resp.ReverseHistoricalCumulativeEnergy(); err != nil {
+		logFailure("ReverseHistoricalCumulativeEnergy", err)
+	} else {
+		setHistorical("reverse", h)
+	}
+	if value, err := resp.InstantaneousElectricPower(); err != nil {
+		logFailure("InstantaneousPower", err)
+	} else {
+		metrics.instantaneousPower.WithLabelValues(labels()...).Set(float64(value))
+	}
+	if currents, err := resp.InstantaneousCurrents(); err != nil {
+		logFailure("InstantaneousCurrents", err)
+	} else {
+		metrics.instantaneousCurrent.WithLabelValues(labels("r")...).Set(currents.R)
+		if !currents.SinglePhase {
+			metrics.instantaneousCurrent.WithLabelValues(labels("t")...).Set(currents.T)
+		}
+	}
+}
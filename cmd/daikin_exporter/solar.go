@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/slog"
+)
+
+type solarMetrics struct {
+	instantaneousPower *prometheus.GaugeVec
+	cumulativeEnergy   *prometheus.GaugeVec
+}
+
+func newSolarMetrics(reg prometheus.Registerer) solarMetrics {
+	commonLabels := []string{"address", "id"}
+
+	metrics := solarMetrics{
+		instantaneousPower: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "solar_instantaneous_power", Help: "instantaneous generated power (unit:W)"},
+			commonLabels,
+		),
+		cumulativeEnergy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "solar_cumulative_energy", Help: "cumulative generated energy (unit:kWh)"},
+			commonLabels,
+		),
+	}
+
+	reg.MustRegister(metrics.instantaneousPower)
+	reg.MustRegister(metrics.cumulativeEnergy)
+
+	return metrics
+}
+
+func (handler *daikinPrometheusHandler) updateSolarMetrics(ctx context.Context) {
+	metrics := handler.solarMetrics
+	metrics.instantaneousPower.Reset()
+	metrics.cumulativeEnergy.Reset()
+
+	for _, target := range handler.solars {
+		resps, err := handler.solar.Request().
+			IdentificationNumber().
+			InstantaneousElectricPowerGeneration().
+			CumulativeElectricEnergyOfGeneration().
+			SetRetry(handler.retry).
+			SetAddress(target).
+			QueryContext(ctx)
+		if err != nil {
+			slog.Info("[updateSolarMetrics] query failed", "address", target.String(), "error", err)
+			continue
+		}
+		for _, resp := range resps {
+			idstr := ""
+			if id, err := resp.IdentificationNumber(); err == nil {
+				idstr = bytesToString(id)
+			}
+
+			if value, err := resp.InstantaneousElectricPowerGeneration(); err != nil {
+				slog.Info("[updateSolarMetrics] update failed", "address", resp.Address.String(), "id", idstr, "property", "InstantaneousElectricPowerGeneration", "error", err)
+			} else {
+				metrics.instantaneousPower.WithLabelValues(resp.Address.String(), idstr).Set(float64(value))
+			}
+			if value, err := resp.CumulativeElectricEnergyOfGeneration(); err != nil {
+				slog.Info("[updateSolarMetrics] update failed", "address", resp.Address.String(), "id", idstr, "property", "CumulativeElectricEnergyOfGeneration", "error", err)
+			} else {
+				metrics.cumulativeEnergy.WithLabelValues(resp.Address.String(), idstr).Set(value)
+			}
+		}
+	}
+}
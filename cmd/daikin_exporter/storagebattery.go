@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+
+	"github.com/int2xx9/daikin-airconditioner/storagebattery"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/slog"
+)
+
+var workingOperationStatusNames = []struct {
+	status storagebattery.WorkingOperationStatus
+	name   string
+}{
+	{storagebattery.WorkingOperationStatusOther, "other"},
+	{storagebattery.WorkingOperationStatusRapidCharging, "rapid_charging"},
+	{storagebattery.WorkingOperationStatusCharging, "charging"},
+	{storagebattery.WorkingOperationStatusDischarging, "discharging"},
+	{storagebattery.WorkingOperationStatusStandby, "standby"},
+	{storagebattery.WorkingOperationStatusTest, "test"},
+	{storagebattery.WorkingOperationStatusAuto, "auto"},
+	{storagebattery.WorkingOperationStatusRestart, "restart"},
+	{storagebattery.WorkingOperationStatusEffectiveCapacityRecalculation, "effective_capacity_recalculation"},
+}
+
+type storageBatteryMetrics struct {
+	workingOperationStatus *prometheus.GaugeVec
+	instantaneousPower     *prometheus.GaugeVec
+	remainingCapacity      *prometheus.GaugeVec
+	stateOfHealth          *prometheus.GaugeVec
+}
+
+func newStorageBatteryMetrics(reg prometheus.Registerer) storageBatteryMetrics {
+	commonLabels := []string{"address", "id"}
+
+	metrics := storageBatteryMetrics{
+		workingOperationStatus: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "storage_battery_working_operation_status", Help: "working operation status (1:on, 0:off)"},
+			append(commonLabels, "status"),
+		),
+		instantaneousPower: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "storage_battery_instantaneous_power", Help: "instantaneous charging power, negative while discharging (unit:W)"},
+			commonLabels,
+		),
+		remainingCapacity: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "storage_battery_remaining_capacity", Help: "remaining stored electricity (0-100%)"},
+			commonLabels,
+		),
+		stateOfHealth: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "storage_battery_state_of_health", Help: "battery state of health (0-100%)"},
+			commonLabels,
+		),
+	}
+
+	reg.MustRegister(metrics.workingOperationStatus)
+	reg.MustRegister(metrics.instantaneousPower)
+	reg.MustRegister(metrics.remainingCapacity)
+	reg.MustRegister(metrics.stateOfHealth)
+
+	return metrics
+}
+
+func (handler *daikinPrometheusHandler) updateStorageBatteryMetrics(ctx context.Context) {
+	metrics := handler.storageBatteryMetrics
+	metrics.workingOperationStatus.Reset()
+	metrics.instantaneousPower.Reset()
+	metrics.remainingCapacity.Reset()
+	metrics.stateOfHealth.Reset()
+
+	for _, target := range handler.storageBatteries {
+		resps, err := handler.storageBattery.Request().
+			IdentificationNumber().
+			WorkingOperationStatus().
+			InstantaneousChargingDischargingElectricPower().
+			RemainingCapacity3().
+			BatteryStateOfHealth().
+			SetRetry(handler.retry).
+			SetAddress(target).
+			QueryContext(ctx)
+		if err != nil {
+			slog.Info("[updateStorageBatteryMetrics] query failed", "address", target.String(), "error", err)
+			continue
+		}
+		for _, resp := range resps {
+			updateStorageBatteryMetrics(resp, metrics)
+		}
+	}
+}
+
+func updateStorageBatteryMetrics(resp storagebattery.QueryResponse, metrics storageBatteryMetrics) {
+	idstr := ""
+	if id, err := resp.IdentificationNumber(); err == nil {
+		idstr = bytesToString(id)
+	}
+	addr := resp.Address.String()
+	logFailure := func(property string, err error) {
+		slog.Info("[updateStorageBatteryMetrics] update failed", "address", addr, "id", idstr, "property", property, "error", err)
+	}
+
+	if value, err := resp.WorkingOperationStatus(); err != nil {
+		logFailure("WorkingOperationStatus", err)
+	} else {
+		for _, s := range workingOperationStatusNames {
+			v := 0.0
+			if s.status == value {
+				v = 1
+			}
+			metrics.workingOperationStatus.WithLabelValues(addr, idstr, s.name).Set(v)
+		}
+	}
+	if value, err := resp.InstantaneousChargingDischargingElectricPower(); err != nil {
+		logFailure("InstantaneousChargingDischargingElectricPower", err)
+	} else {
+		metrics.instantaneousPower.WithLabelValues(addr, idstr).Set(float64(value))
+	}
+	if value, err := resp.RemainingCapacity3(); err != nil {
+		logFailure("RemainingCapacity3", err)
+	} else {
+		metrics.remainingCapacity.WithLabelValues(addr, idstr).Set(float64(value))
+	}
+	if value, err := resp.BatteryStateOfHealth(); err != nil {
+		logFailure("BatteryStateOfHealth", err)
+	} else {
+		metrics.stateOfHealth.WithLabelValues(addr, idstr).Set(float64(value))
+	}
+}
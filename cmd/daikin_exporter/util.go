@@ -2,9 +2,14 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"net"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/int2xx9/daikin-airconditioner/daikin"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/exp/constraints"
 )
@@ -17,6 +22,61 @@ func bytesToString(data []byte) string {
 	return s
 }
 
+// createCapture creates a capture file and returns a writer to it and the
+// file, which the caller closes on exit.
+func createCapture(name string) (echonetlite.PacketWriter, io.Closer, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var w echonetlite.PacketWriter
+	if strings.HasSuffix(name, ".pcap") {
+		w, err = echonetlite.NewPcapWriter(f)
+	} else {
+		w, err = echonetlite.NewPcapngWriter(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return w, f, nil
+}
+
+func parseTargets(s string) ([]net.UDPAddr, error) {
+	targets := []net.UDPAddr{}
+	for _, target := range strings.Split(s, ",") {
+		target = strings.TrimSpace(target)
+		if target == "" {
+			continue
+		}
+		addr, err := parseAddress(target)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, *addr)
+	}
+	return targets, nil
+}
+
+// setDefaultZone sets zone to link-local addresses without a zone, which are
+// ambiguous.
+func setDefaultZone(addrs []net.UDPAddr, zone string) {
+	for i := range addrs {
+		if addrs[i].IP.IsLinkLocalUnicast() && addrs[i].Zone == "" {
+			addrs[i].Zone = zone
+		}
+	}
+}
+
+// parseAddress resolves an address whose port defaults to 3610.
+func parseAddress(s string) (*net.UDPAddr, error) {
+	if _, _, err := net.SplitHostPort(s); err != nil {
+		s = net.JoinHostPort(s, strconv.Itoa(echonetlite.DefaultPort))
+	}
+	return net.ResolveUDPAddr("udp", s)
+}
+
 func updateBoolMetrics(addr net.UDPAddr, id string, getter func() (value bool, err error), gaugeVec *prometheus.GaugeVec) error {
 	value, err := getter()
 	if err != nil {
@@ -80,3 +140,11 @@ func updateOperationMode(addr net.UDPAddr, id string, getter func() (value daiki
 	}
 	return nil
 }
+
+func updateSupportedPropertyMetrics(addr net.UDPAddr, id string, caps echonetlite.Capabilities, gaugeVec *prometheus.GaugeVec) {
+	for access, epcs := range map[string][]byte{"announce": caps.Announce, "set": caps.Set, "get": caps.Get} {
+		for _, epc := range epcs {
+			gaugeVec.WithLabelValues(addr.String(), id, fmt.Sprintf("%02x", epc), access).Set(1)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/int2xx9/daikin-airconditioner/daikinsim"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	optionAddress     = flag.String("address", "127.0.0.1:3611", "address of the first unit; the port is incremented for each of the other units")
+	optionNetwork     = flag.String("network", "udp4", "network to listen on (udp4, udp6 or udp for dual stack)")
+	optionUnits       = flag.Int("units", 1, "number of units")
+	optionInstances   = flag.Int("instances", 1, "number of air conditioner objects in each unit")
+	optionUnsupported = flag.String("unsupported", "", "comma-separated EPCs answered with Get_SNA (e.g. b4,be)")
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	slog.SetDefault(logger)
+
+	flag.Parse()
+
+	addr, err := net.ResolveUDPAddr("udp", *optionAddress)
+	if err != nil {
+		slog.Error("invalid address", "error", err)
+		os.Exit(1)
+	}
+	unsupported, err := parseEpcs(*optionUnsupported)
+	if err != nil {
+		slog.Error("invalid EPCs", "error", err)
+		os.Exit(1)
+	}
+
+	units := []*daikinsim.Unit{}
+	for i := 0; i < *optionUnits; i++ {
+		states := []daikinsim.State{}
+		for j := 0; j < *optionInstances; j++ {
+			state := daikinsim.DefaultState()
+			state.IdentificationNumber[14] = byte(i)
+			state.IdentificationNumber[15] = byte(j)
+			state.Unsupported = unsupported
+			states = append(states, state)
+		}
+
+		unitAddr := *addr
+		unitAddr.Port += i
+		unit := daikinsim.NewUnit(states...)
+		unit.SetLogger(logger)
+		if err := unit.ListenAndStart(*optionNetwork, &unitAddr); err != nil {
+			slog.Error("failed to start a unit", "address", unitAddr.String(), "error", err)
+			os.Exit(1)
+		}
+		slog.Info("unit started", "address", unitAddr.String(), "instances", *optionInstances)
+		units = append(units, unit)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	<-signals
+
+	for _, unit := range units {
+		unit.Stop()
+	}
+}
+
+func parseEpcs(s string) ([]byte, error) {
+	epcs := []byte{}
+	for _, epc := range strings.Split(s, ",") {
+		epc = strings.TrimPrefix(strings.TrimSpace(epc), "0x")
+		if epc == "" {
+			continue
+		}
+		v, err := strconv.ParseUint(epc, 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		epcs = append(epcs, byte(v))
+	}
+	return epcs, nil
+}
@@ -0,0 +1,732 @@
+// Command echonetgen generates typed accessors of properties of an ECHONET
+// Lite class from the Machine Readable Appendix (MRA) for go generate.
+//
+// The generated code is meant for a package like daikin. With -request, it
+// includes QueryRequest and QueryResponse wrapping echonetlite.PropertyRequest
+// and echonetlite.PropertyResponse, and the errors of the package. Otherwise
+// the package needs to define them.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/mra"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+var (
+	optionMRA     = flag.String("mra", "", "mraData directory of the MRA or a local spec in the same layout (default: embedded subset)")
+	optionClass   = flag.String("class", "", "class group code and class code to generate (e.g. 0x0130)")
+	optionPackage = flag.String("package", "", "package name of the generated code")
+	optionSkip    = flag.String("skip", "", "comma-separated EPCs which have hand-written accessors (e.g. 80,b0)")
+	optionOutput  = flag.String("output", "", "file to write the generated code to (default: stdout)")
+	optionRequest = flag.Bool("request", false, "also generate QueryRequest, QueryResponse and errors wrapping echonetlite")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(); err != nil {
+		slog.Error("failed to generate", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	db := mra.Default()
+	if *optionMRA != "" {
+		var err error
+		if db, err = mra.LoadDir(*optionMRA); err != nil {
+			return err
+		}
+	}
+	code, err := strconv.ParseUint(strings.TrimPrefix(*optionClass, "0x"), 16, 16)
+	if err != nil {
+		return fmt.Errorf("invalid class: %w", err)
+	}
+	class, ok := db.Class(uint16(code))
+	if !ok {
+		return fmt.Errorf("class 0x%04x is not defined", code)
+	}
+	if *optionPackage == "" {
+		return fmt.Errorf("no package")
+	}
+	skip, err := parseEpcs(*optionSkip)
+	if err != nil {
+		return fmt.Errorf("invalid EPCs to skip: %w", err)
+	}
+
+	properties := []*mra.Property{}
+	for _, p := range class.Properties {
+		if !slices.Contains(skip, p.Epc) && p.AccessRule.Get.Applicable() {
+			properties = append(properties, p)
+		}
+	}
+	src, err := generate(*optionPackage, class, properties, *optionRequest)
+	if err != nil {
+		return err
+	}
+
+	if *optionOutput == "" {
+		_, err := os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*optionOutput, src, 0644)
+}
+
+func parseEpcs(s string) ([]byte, error) {
+	epcs := []byte{}
+	for _, epc := range strings.Split(s, ",") {
+		if epc == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimPrefix(epc, "0x"), 16, 8)
+		if err != nil {
+			return nil, err
+		}
+		epcs = append(epcs, byte(value))
+	}
+	return epcs, nil
+}
+
+type generator struct {
+	class   *mra.Class
+	imports map[string]bool
+	plumb   bytes.Buffer
+	consts  bytes.Buffer
+	types   bytes.Buffer
+	request bytes.Buffer
+	decode  bytes.Buffer
+	encode  bytes.Buffer
+}
+
+func generate(pkg string, class *mra.Class, properties []*mra.Property, request bool) ([]byte, error) {
+	g := &generator{class: class, imports: map[string]bool{}}
+	if request {
+		g.plumbing()
+	}
+	for _, p := range properties {
+		g.property(p)
+	}
+
+	src := &bytes.Buffer{}
+	fmt.Fprintf(src, "// Code generated by echonetgen for %s (0x%04x); DO NOT EDIT.\n\n", class.Name, class.Code)
+	fmt.Fprintf(src, "package %s\n\n", pkg)
+	if len(g.imports) > 0 {
+		imports := maps.Keys(g.imports)
+		slices.SortFunc(imports, func(a, b string) int {
+			if aStd, bStd := !strings.Contains(a, "."), !strings.Contains(b, "."); aStd != bStd {
+				if aStd {
+					return -1
+				}
+				return 1
+			}
+			return strings.Compare(a, b)
+		})
+		fmt.Fprintf(src, "import (\n")
+		for i, path := range imports {
+			if i > 0 && !strings.Contains(imports[i-1], ".") && strings.Contains(path, ".") {
+				// separate the standard library
+				fmt.Fprintf(src, "\n")
+			}
+			fmt.Fprintf(src, "%q\n", path)
+		}
+		fmt.Fprintf(src, ")\n\n")
+	}
+	src.Write(g.plumb.Bytes())
+	fmt.Fprintf(src, "const (\n%s)\n\n", g.consts.Bytes())
+	src.Write(g.types.Bytes())
+	src.Write(g.request.Bytes())
+	src.Write(g.decode.Bytes())
+	src.Write(g.encode.Bytes())
+	return format.Source(src.Bytes())
+}
+
+// plumbing generates the errors, QueryRequest and QueryResponse, which are
+// the same for every class but the document of QueryRequest.
+func (g *generator) plumbing() {
+	g.imports["context"] = true
+	g.imports["net"] = true
+	g.imports["github.com/int2xx9/daikin-airconditioner/echonetlite"] = true
+	fmt.Fprintf(&g.plumb, `var (
+	ErrNoResponsesForEpc    = echonetlite.ErrNoResponsesForEpc
+	ErrPropertyNotAvailable = echonetlite.ErrPropertyNotAvailable
+	ErrPropertyNotSupported = echonetlite.ErrPropertyNotSupported
+	ErrUnexpectedValue      = echonetlite.ErrUnexpectedValue
+	ErrUnsupportedValue     = echonetlite.ErrUnsupportedValue
+)
+
+// QueryRequest requests properties of %[1]s objects (0x%04[2]x).
+// It is immutable like echonetlite.PropertyRequest.
+type QueryRequest struct {
+	req echonetlite.PropertyRequest
+}
+
+func (r QueryRequest) Query() ([]QueryResponse, error) {
+	return r.QueryContext(context.Background())
+}
+
+// QueryContext queries devices until ctx is done or the timeout expires. A
+// query to an address specified by SetAddress returns as soon as the device
+// responds.
+//
+// See echonetlite.QueryBuilder.GetProperties for EPCs which are not in the
+// Get property map of a device, and responses to a query to all instances.
+// A device which responds with Get_SNA is returned as a partial
+// QueryResponse. See QueryResponse.Err for properties without a value.
+func (r QueryRequest) QueryContext(ctx context.Context) ([]QueryResponse, error) {
+	responses, err := r.req.Get(ctx)
+	if err != nil {
+		return []QueryResponse{}, err
+	}
+
+	retResponses := []QueryResponse{}
+	for _, res := range responses {
+		retResponses = append(retResponses, QueryResponse{res})
+	}
+	return retResponses, nil
+}
+
+// SetInstance sends the request to the object with the instance code
+// instead of the first one. An instance code of 0 addresses all of the
+// objects in a device, and each of them responds.
+func (r QueryRequest) SetInstance(instanceCode byte) QueryRequest {
+	r.req = r.req.WithInstance(instanceCode)
+	return r
+}
+
+// SetAddress sends the request only to the device at addr.
+func (r QueryRequest) SetAddress(addr net.UDPAddr) QueryRequest {
+	r.req = r.req.WithAddress(addr)
+	return r
+}
+
+// SetRetry retries the request according to policy if responses are lost.
+// The timeout applies to each attempt.
+func (r QueryRequest) SetRetry(policy echonetlite.RetryPolicy) QueryRequest {
+	r.req = r.req.WithRetry(policy)
+	return r
+}
+
+func (r QueryRequest) AddEpc(epc byte) QueryRequest {
+	r.req = r.req.WithEpc(epc)
+	return r
+}
+
+// IdentificationNumber requests Identification number (0x83) of the super
+// class.
+func (r QueryRequest) IdentificationNumber() QueryRequest {
+	return r.AddEpc(echonetlite.EpcIdentificationNumber)
+}
+
+// QueryResponse is the properties of an object in a response to a
+// QueryRequest.
+type QueryResponse struct {
+	echonetlite.PropertyResponse
+}
+
+`, strings.ToLower(g.class.Name), g.class.Code)
+}
+
+// exportedName converts a short name in the MRA, e.g. "operationStatus", to
+// an exported identifier.
+func exportedName(shortName string) string {
+	if shortName == "" {
+		return ""
+	}
+	return strings.ToUpper(shortName[:1]) + shortName[1:]
+}
+
+func (g *generator) property(p *mra.Property) {
+	name := exportedName(p.ShortName)
+	if name == "" {
+		name = fmt.Sprintf("Property%02X", p.Epc)
+	}
+	epc := "Epc" + name
+	fmt.Fprintf(&g.consts, "%s byte = 0x%02x\n", epc, p.Epc)
+	fmt.Fprintf(&g.request, "// %s requests %s (0x%02x).\n", name, p.Name, p.Epc)
+	fmt.Fprintf(&g.request, "func (r QueryRequest) %s() QueryRequest {\nreturn r.AddEpc(%s)\n}\n\n", name, epc)
+
+	settable := p.AccessRule.Set.Applicable()
+	t := p.Data
+	switch {
+	case isBoolState(t):
+		g.boolState(name, epc, p, settable)
+	case isEnum(t):
+		g.enum(name, epc, p, settable)
+	case numberOf(t) != nil:
+		g.number(name, epc, p, numberOf(t), settable)
+	case isLevelOrAuto(t):
+		g.levelOrAuto(name, epc, p, settable)
+	case t.Type == "time":
+		g.time(name, epc, p, settable)
+	case t.Type == "date":
+		g.date(name, epc, p, settable)
+	case isFlags(t):
+		g.flags(name, epc, p, settable)
+	case t.Type == "raw":
+		g.raw(name, epc, p, settable)
+	default:
+		g.generic(name, epc, p, settable)
+	}
+}
+
+func parseCode(s string) byte {
+	value, _ := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 8)
+	return byte(value)
+}
+
+func isBoolState(t *mra.DataType) bool {
+	if t.Type != "state" || t.FixedSize() != 1 || len(t.Enum) != 2 {
+		return false
+	}
+	return t.Enum[0].Name == "true" && t.Enum[1].Name == "false"
+}
+
+func isEnum(t *mra.DataType) bool {
+	if t.Type != "state" || t.FixedSize() != 1 {
+		return false
+	}
+	for _, e := range t.Enum {
+		if e.Name == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// numberOf returns the number of a number or a number with special states,
+// e.g. undefined.
+func numberOf(t *mra.DataType) *mra.DataType {
+	if t.Type == "number" {
+		return t
+	}
+	if len(t.OneOf) < 2 || t.OneOf[0].Type != "number" {
+		return nil
+	}
+	for _, alt := range t.OneOf[1:] {
+		if alt.Type != "state" {
+			return nil
+		}
+	}
+	return t.OneOf[0]
+}
+
+func isLevelOrAuto(t *mra.DataType) bool {
+	return len(t.OneOf) == 2 && t.OneOf[0].Type == "level" &&
+		t.OneOf[1].Type == "state" && len(t.OneOf[1].Enum) == 1 && t.OneOf[1].Enum[0].Name == "auto"
+}
+
+// isFlags reports whether t is a bitmap whose fields are single bits.
+func isFlags(t *mra.DataType) bool {
+	if t.Type != "bitmap" {
+		return false
+	}
+	for _, field := range t.Bitmaps {
+		mask := parseMask(field.Position.BitMask)
+		if mask == 0 || mask&(mask-1) != 0 || !isFlag(field.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func isFlag(t *mra.DataType) bool {
+	if t.Type != "state" || len(t.Enum) != 2 {
+		return false
+	}
+	return parseCode(t.Enum[0].Edt) == 0 && t.Enum[0].Name == "false" && parseCode(t.Enum[1].Edt) == 1 && t.Enum[1].Name == "true"
+}
+
+func parseMask(s string) byte {
+	if strings.HasPrefix(s, "0b") {
+		value, _ := strconv.ParseUint(s[2:], 2, 8)
+		return byte(value)
+	}
+	return parseCode(s)
+}
+
+func (g *generator) boolState(name, epc string, p *mra.Property, settable bool) {
+	on, off := parseCode(p.Data.Enum[0].Edt), parseCode(p.Data.Enum[1].Edt)
+	fmt.Fprintf(&g.decode, `func (q QueryResponse) %s() (bool, error) {
+	data, err := q.FixedEdt(%s, 1)
+	if err != nil {
+		return false, err
+	}
+
+	switch data[0] {
+	case 0x%02x:
+		return true, nil
+	case 0x%02x:
+		return false, nil
+	default:
+		return false, ErrUnexpectedValue
+	}
+}
+
+`, name, epc, on, off)
+	if settable {
+		fmt.Fprintf(&g.encode, `func Encode%s(value bool) ([]byte, error) {
+	if value {
+		return []byte{0x%02x}, nil
+	}
+	return []byte{0x%02x}, nil
+}
+
+`, name, on, off)
+	}
+}
+
+func (g *generator) enum(name, epc string, p *mra.Property, settable bool) {
+	fmt.Fprintf(&g.types, "// %s is a value of %s (0x%02x).\ntype %s byte\n\nconst (\n", name, p.Name, p.Epc, name)
+	values := []string{}
+	for _, e := range p.Data.Enum {
+		value := name + exportedName(e.Name)
+		values = append(values, value)
+		fmt.Fprintf(&g.types, "%s %s = 0x%02x\n", value, name, parseCode(e.Edt))
+	}
+	fmt.Fprintf(&g.types, ")\n\n")
+
+	fmt.Fprintf(&g.decode, `func (q QueryResponse) %s() (%s, error) {
+	data, err := q.FixedEdt(%s, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := %s(data[0]); value {
+	case %s:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+`, name, name, epc, name, strings.Join(values, ", "))
+	if settable {
+		fmt.Fprintf(&g.encode, `func Encode%s(value %s) ([]byte, error) {
+	switch value {
+	case %s:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+`, name, name, strings.Join(values, ", "))
+	}
+}
+
+// scale returns the Go type of a number and the expressions to convert an
+// EDT to the value and the value to an EDT.
+func scale(n *mra.DataType) (goType string, toValue string, toEdt string) {
+	switch {
+	case n.MultipleOf == 0 || n.MultipleOf == 1:
+		return "int", "raw", "value"
+	case n.MultipleOf < 1:
+		divisor := strconv.FormatFloat(1/n.MultipleOf, 'f', 0, 64)
+		return "float64", "float64(raw) / " + divisor, "int(math.Round(value * " + divisor + "))"
+	default:
+		multiplier := strconv.FormatFloat(n.MultipleOf, 'f', -1, 64)
+		return "float64", "float64(raw) * " + multiplier, "int(math.Round(value / " + multiplier + "))"
+	}
+}
+
+func (g *generator) number(name, epc string, p *mra.Property, n *mra.DataType, settable bool) {
+	size := n.FixedSize()
+	var read, write string
+	switch n.Format {
+	case "int8":
+		read, write = "int(int8(data[0]))", "[]byte{byte(raw)}"
+	case "uint8":
+		read, write = "int(data[0])", "[]byte{byte(raw)}"
+	case "int16":
+		read, write = "int(int16(binary.BigEndian.Uint16(data)))", "binary.BigEndian.AppendUint16(nil, uint16(raw))"
+	case "uint16":
+		read, write = "int(binary.BigEndian.Uint16(data))", "binary.BigEndian.AppendUint16(nil, uint16(raw))"
+	case "int32":
+		read, write = "int(int32(binary.BigEndian.Uint32(data)))", "binary.BigEndian.AppendUint32(nil, uint32(raw))"
+	default:
+		read, write = "int(binary.BigEndian.Uint32(data))", "binary.BigEndian.AppendUint32(nil, uint32(raw))"
+	}
+	if size > 1 {
+		g.imports["encoding/binary"] = true
+	}
+	goType, toValue, toEdt := scale(n)
+	// raw of an unsigned EDT can't be negative
+	unsigned := !strings.HasPrefix(n.Format, "int")
+	check := ""
+	if cond := rangeCheck(n, unsigned); cond != "" {
+		check = fmt.Sprintf("if %s {\nreturn 0, ErrUnsupportedValue\n}\n", cond)
+	}
+
+	doc := ""
+	if n.Unit != "" {
+		doc = fmt.Sprintf("// %s returns %s in %s.", name, p.Name, n.Unit)
+		if len(n.Coefficient) > 0 {
+			doc += fmt.Sprintf(" It needs to be multiplied by the values of %s.", strings.Join(n.Coefficient, ", "))
+		}
+		doc += "\n"
+	}
+	fmt.Fprintf(&g.decode, `%sfunc (q QueryResponse) %s() (%s, error) {
+	data, err := q.FixedEdt(%s, %d)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := %s
+	%sreturn %s, nil
+}
+
+`, doc, name, goType, epc, size, read, check, toValue)
+	if settable {
+		if goType == "float64" {
+			g.imports["math"] = true
+		}
+		encodeCheck := ""
+		if cond := rangeCheck(n, false); cond != "" {
+			encodeCheck = fmt.Sprintf("if %s {\nreturn nil, ErrUnsupportedValue\n}\n", cond)
+		}
+		fmt.Fprintf(&g.encode, `func Encode%s(value %s) ([]byte, error) {
+	raw := %s
+	%sreturn %s, nil
+}
+
+`, name, goType, toEdt, encodeCheck, write)
+	}
+}
+
+// rangeCheck returns the condition of raw out of the range of n, or an empty
+// string if any raw is in the range. The minimum is not checked if it is 0 or
+// less and raw is unsigned.
+func rangeCheck(n *mra.DataType, unsigned bool) string {
+	conditions := []string{}
+	if n.Minimum != nil && !(unsigned && *n.Minimum <= 0) {
+		conditions = append(conditions, "raw < "+strconv.FormatFloat(*n.Minimum, 'f', -1, 64))
+	}
+	if n.Maximum != nil {
+		conditions = append(conditions, "raw > "+strconv.FormatFloat(*n.Maximum, 'f', -1, 64))
+	}
+	return strings.Join(conditions, " || ")
+}
+
+func (g *generator) levelOrAuto(name, epc string, p *mra.Property, settable bool) {
+	level, auto := p.Data.OneOf[0], p.Data.OneOf[1]
+	base := parseCode(level.Base)
+	maximum := int(*level.Maximum)
+	autoCode := parseCode(auto.Enum[0].Edt)
+	fmt.Fprintf(&g.decode, `// %s returns the level from 1 to %d, or true if it is automatic.
+func (q QueryResponse) %s() (int, bool, error) {
+	data, err := q.FixedEdt(%s, 1)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if data[0] == 0x%02x {
+		return 0, true, nil
+	}
+	if data[0] < 0x%02x || data[0] > 0x%02x {
+		return 0, false, ErrUnsupportedValue
+	}
+	return int(data[0]-0x%02x) + 1, false, nil
+}
+
+`, name, maximum, name, epc, autoCode, base, int(base)+maximum-1, base)
+	if settable {
+		fmt.Fprintf(&g.encode, `func Encode%s(level int, auto bool) ([]byte, error) {
+	if auto {
+		return []byte{0x%02x}, nil
+	}
+	if level < 1 || level > %d {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(0x%02x + level - 1)}, nil
+}
+
+`, name, autoCode, maximum, base)
+	}
+}
+
+func (g *generator) time(name, epc string, p *mra.Property, settable bool) {
+	g.imports["time"] = true
+	size := p.Data.FixedSize()
+	// The MRA defines times of day and relative times with the same type, but
+	// only relative times, e.g. of timers, may exceed 23 hours.
+	from, maxHour := "midnight", 23
+	if strings.Contains(p.ShortName, "RelativeTime") {
+		from, maxHour = "now", 255
+	}
+	check, seconds := "data[1] > 59", ""
+	if maxHour < 255 {
+		check = fmt.Sprintf("data[0] > %d || %s", maxHour, check)
+	}
+	if size > 2 {
+		check += " || data[2] > 59"
+		seconds = " + time.Duration(data[2])*time.Second"
+	}
+	fmt.Fprintf(&g.decode, `// %s returns %s as a duration from %s.
+func (q QueryResponse) %s() (time.Duration, error) {
+	data, err := q.FixedEdt(%s, %d)
+	if err != nil {
+		return 0, err
+	}
+
+	if %s {
+		return 0, ErrUnsupportedValue
+	}
+	return time.Duration(data[0])*time.Hour + time.Duration(data[1])*time.Minute%s, nil
+}
+
+`, name, p.Name, from, name, epc, size, check, seconds)
+	if settable {
+		precision, edt := "time.Minute", "[]byte{byte(value / time.Hour), byte(value % time.Hour / time.Minute)}"
+		if size > 2 {
+			precision, edt = "time.Second", "[]byte{byte(value / time.Hour), byte(value % time.Hour / time.Minute), byte(value % time.Minute / time.Second)}"
+		}
+		fmt.Fprintf(&g.encode, `func Encode%s(value time.Duration) ([]byte, error) {
+	if value < 0 || value >= %d*time.Hour || value%%%s != 0 {
+		return nil, ErrUnsupportedValue
+	}
+	return %s, nil
+}
+
+`, name, maxHour+1, precision, edt)
+	}
+}
+
+func (g *generator) date(name, epc string, p *mra.Property, settable bool) {
+	g.imports["time"] = true
+	g.imports["encoding/binary"] = true
+	fmt.Fprintf(&g.decode, `// %s returns %s in UTC since the device has no time zone.
+func (q QueryResponse) %s() (time.Time, error) {
+	data, err := q.FixedEdt(%s, 4)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	date := time.Date(int(binary.BigEndian.Uint16(data)), time.Month(data[2]), int(data[3]), 0, 0, 0, 0, time.UTC)
+	if date.Month() != time.Month(data[2]) || date.Day() != int(data[3]) {
+		return time.Time{}, ErrUnsupportedValue
+	}
+	return date, nil
+}
+
+`, name, p.Name, name, epc)
+	if settable {
+		fmt.Fprintf(&g.encode, `func Encode%s(value time.Time) ([]byte, error) {
+	if value.Year() < 0 || value.Year() > 0xffff {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(value.Year() >> 8), byte(value.Year()), byte(value.Month()), byte(value.Day())}, nil
+}
+
+`, name)
+	}
+}
+
+func (g *generator) flags(name, epc string, p *mra.Property, settable bool) {
+	size := p.Data.FixedSize()
+	fmt.Fprintf(&g.types, "// %s is a value of %s (0x%02x).\ntype %s struct {\n", name, p.Name, p.Epc, name)
+	for _, field := range p.Data.Bitmaps {
+		fmt.Fprintf(&g.types, "%s bool\n", exportedName(field.Name))
+	}
+	fmt.Fprintf(&g.types, "}\n\n")
+
+	fields := &bytes.Buffer{}
+	sets := &bytes.Buffer{}
+	for _, field := range p.Data.Bitmaps {
+		mask := parseMask(field.Position.BitMask)
+		fmt.Fprintf(fields, "%s: data[%d]&0x%02x != 0,\n", exportedName(field.Name), field.Position.Index, mask)
+		fmt.Fprintf(sets, "if value.%s {\ndata[%d] |= 0x%02x\n}\n", exportedName(field.Name), field.Position.Index, mask)
+	}
+	fmt.Fprintf(&g.decode, `func (q QueryResponse) %s() (%s, error) {
+	data, err := q.FixedEdt(%s, %d)
+	if err != nil {
+		return %s{}, err
+	}
+
+	return %s{
+%s}, nil
+}
+
+`, name, name, epc, size, name, name, fields.Bytes())
+	if settable {
+		fmt.Fprintf(&g.encode, `func Encode%s(value %s) ([]byte, error) {
+	data := make([]byte, %d)
+%sreturn data, nil
+}
+
+`, name, name, size, sets.Bytes())
+	}
+}
+
+func (g *generator) raw(name, epc string, p *mra.Property, settable bool) {
+	get := fmt.Sprintf("q.FixedEdt(%s, %d)", epc, p.Data.MinSize)
+	check := ""
+	if p.Data.MaxSize != p.Data.MinSize {
+		get = fmt.Sprintf("q.Edt(%s)", epc)
+		check = fmt.Sprintf("len(data) < %d", p.Data.MinSize)
+		if p.Data.MaxSize > 0 {
+			check += fmt.Sprintf(" || len(data) > %d", p.Data.MaxSize)
+		}
+		check = fmt.Sprintf("if %s {\nreturn nil, ErrUnexpectedValue\n}\n", check)
+	}
+	fmt.Fprintf(&g.decode, `func (q QueryResponse) %s() ([]byte, error) {
+	data, err := %s
+	if err != nil {
+		return nil, err
+	}
+	%s
+	return append([]byte{}, data...), nil
+}
+
+`, name, get, check)
+	if settable {
+		check := fmt.Sprintf("len(value) != %d", p.Data.MinSize)
+		if p.Data.MaxSize != p.Data.MinSize {
+			check = fmt.Sprintf("len(value) < %d", p.Data.MinSize)
+			if p.Data.MaxSize > 0 {
+				check += fmt.Sprintf(" || len(value) > %d", p.Data.MaxSize)
+			}
+		}
+		fmt.Fprintf(&g.encode, `func Encode%s(value []byte) ([]byte, error) {
+	if %s {
+		return nil, ErrUnsupportedValue
+	}
+	return append([]byte{}, value...), nil
+}
+
+`, name, check)
+	}
+}
+
+// generic decodes and encodes values with the MRA at runtime for data types
+// which have no Go types, e.g. objects.
+func (g *generator) generic(name, epc string, p *mra.Property, settable bool) {
+	g.imports["github.com/int2xx9/daikin-airconditioner/echonetlite/mra"] = true
+	fmt.Fprintf(&g.decode, `func (q QueryResponse) %s() (mra.Value, error) {
+	data, err := q.Edt(%s)
+	if err != nil {
+		return nil, err
+	}
+
+	return mra.Default().Decode(q.Object, %s, data)
+}
+
+`, name, epc, epc)
+	if settable {
+		g.imports["github.com/int2xx9/daikin-airconditioner/echonetlite"] = true
+		fmt.Fprintf(&g.encode, `func Encode%s(value mra.Value) ([]byte, error) {
+	return mra.Default().Encode(echonetlite.NewEOJ(0x%02x, 0x%02x, 0x00), %s, value)
+}
+
+`, name, g.class.Code>>8, g.class.Code&0xff, epc)
+	}
+}
@@ -0,0 +1,1153 @@
+// Code generated by echonetgen for Home air conditioner (0x0130); DO NOT EDIT.
+
+package daikin
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"net"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/mra"
+)
+
+var (
+	ErrNoResponsesForEpc    = echonetlite.ErrNoResponsesForEpc
+	ErrPropertyNotAvailable = echonetlite.ErrPropertyNotAvailable
+	ErrPropertyNotSupported = echonetlite.ErrPropertyNotSupported
+	ErrUnexpectedValue      = echonetlite.ErrUnexpectedValue
+	ErrUnsupportedValue     = echonetlite.ErrUnsupportedValue
+)
+
+// QueryRequest requests properties of home air conditioner objects (0x0130).
+// It is immutable like echonetlite.PropertyRequest.
+type QueryRequest struct {
+	req echonetlite.PropertyRequest
+}
+
+func (r QueryRequest) Query() ([]QueryResponse, error) {
+	return r.QueryContext(context.Background())
+}
+
+// QueryContext queries devices until ctx is done or the timeout expires. A
+// query to an address specified by SetAddress returns as soon as the device
+// responds.
+//
+// See echonetlite.QueryBuilder.GetProperties for EPCs which are not in the
+// Get property map of a device, and responses to a query to all instances.
+// A device which responds with Get_SNA is returned as a partial
+// QueryResponse. See QueryResponse.Err for properties without a value.
+func (r QueryRequest) QueryContext(ctx context.Context) ([]QueryResponse, error) {
+	responses, err := r.req.Get(ctx)
+	if err != nil {
+		return []QueryResponse{}, err
+	}
+
+	retResponses := []QueryResponse{}
+	for _, res := range responses {
+		retResponses = append(retResponses, QueryResponse{res})
+	}
+	return retResponses, nil
+}
+
+// SetInstance sends the request to the object with the instance code
+// instead of the first one. An instance code of 0 addresses all of the
+// objects in a device, and each of them responds.
+func (r QueryRequest) SetInstance(instanceCode byte) QueryRequest {
+	r.req = r.req.WithInstance(instanceCode)
+	return r
+}
+
+// SetAddress sends the request only to the device at addr.
+func (r QueryRequest) SetAddress(addr net.UDPAddr) QueryRequest {
+	r.req = r.req.WithAddress(addr)
+	return r
+}
+
+// SetRetry retries the request according to policy if responses are lost.
+// The timeout applies to each attempt.
+func (r QueryRequest) SetRetry(policy echonetlite.RetryPolicy) QueryRequest {
+	r.req = r.req.WithRetry(policy)
+	return r
+}
+
+func (r QueryRequest) AddEpc(epc byte) QueryRequest {
+	r.req = r.req.WithEpc(epc)
+	return r
+}
+
+// IdentificationNumber requests Identification number (0x83) of the super
+// class.
+func (r QueryRequest) IdentificationNumber() QueryRequest {
+	return r.AddEpc(echonetlite.EpcIdentificationNumber)
+}
+
+// QueryResponse is the properties of an object in a response to a
+// QueryRequest.
+type QueryResponse struct {
+	echonetlite.PropertyResponse
+}
+
+const (
+	EpcPowerSaving                          byte = 0x8f
+	EpcOnTimerReservation                   byte = 0x90
+	EpcOnTimerTime                          byte = 0x91
+	EpcOnTimerRelativeTime                  byte = 0x92
+	EpcOffTimerReservation                  byte = 0x94
+	EpcOffTimerTime                         byte = 0x95
+	EpcOffTimerRelativeTime                 byte = 0x96
+	EpcAutomaticControlAirFlowDirection     byte = 0xa1
+	EpcAutomaticSwingAirFlow                byte = 0xa3
+	EpcAirFlowDirectionVertical             byte = 0xa4
+	EpcAirFlowDirectionHorizontal           byte = 0xa5
+	EpcSpecialState                         byte = 0xaa
+	EpcNonPriorityState                     byte = 0xab
+	EpcAutomaticTemperatureControl          byte = 0xb1
+	EpcHighSpeedOperation                   byte = 0xb2
+	EpcTargetTemperatureInCoolingMode       byte = 0xb5
+	EpcTargetTemperatureInHeatingMode       byte = 0xb6
+	EpcTargetTemperatureInDehumidifyingMode byte = 0xb7
+	EpcRatedPowerConsumption                byte = 0xb8
+	EpcCurrentConsumption                   byte = 0xb9
+	EpcUserRemoteControlSettingTemperature  byte = 0xbc
+	EpcAirTemperature                       byte = 0xbd
+	EpcRelativeTemperature                  byte = 0xbf
+	EpcVentilationMode                      byte = 0xc0
+	EpcHumidifierFunction                   byte = 0xc1
+	EpcVentilationAirFlowLevel              byte = 0xc2
+	EpcHumidificationLevel                  byte = 0xc4
+	EpcMountedAirCleaningMethod             byte = 0xc6
+	EpcAirPurifierFunction                  byte = 0xc7
+	EpcMountedAirRefreshMethod              byte = 0xc8
+	EpcAirRefresherFunction                 byte = 0xc9
+	EpcMountedSelfCleaningMethod            byte = 0xca
+	EpcSelfCleaningFunction                 byte = 0xcb
+	EpcSpecialFunction                      byte = 0xcc
+	EpcComponentsOperationStatus            byte = 0xcd
+	EpcThermostatOverride                   byte = 0xce
+	EpcAirPurificationMode                  byte = 0xcf
+)
+
+// PowerSaving is a value of Power-saving operation setting (0x8f).
+type PowerSaving byte
+
+const (
+	PowerSavingPowerSaving PowerSaving = 0x41
+	PowerSavingNormal      PowerSaving = 0x42
+)
+
+// AutomaticControlAirFlowDirection is a value of Automatic control of air flow direction setting (0xa1).
+type AutomaticControlAirFlowDirection byte
+
+const (
+	AutomaticControlAirFlowDirectionAuto           AutomaticControlAirFlowDirection = 0x41
+	AutomaticControlAirFlowDirectionNonAuto        AutomaticControlAirFlowDirection = 0x42
+	AutomaticControlAirFlowDirectionAutoVertical   AutomaticControlAirFlowDirection = 0x43
+	AutomaticControlAirFlowDirectionAutoHorizontal AutomaticControlAirFlowDirection = 0x44
+)
+
+// AutomaticSwingAirFlow is a value of Automatic swing of air flow setting (0xa3).
+type AutomaticSwingAirFlow byte
+
+const (
+	AutomaticSwingAirFlowOff                   AutomaticSwingAirFlow = 0x31
+	AutomaticSwingAirFlowVertical              AutomaticSwingAirFlow = 0x41
+	AutomaticSwingAirFlowHorizontal            AutomaticSwingAirFlow = 0x42
+	AutomaticSwingAirFlowVerticalAndHorizontal AutomaticSwingAirFlow = 0x43
+)
+
+// AirFlowDirectionVertical is a value of Air flow direction (vertical) setting (0xa4).
+type AirFlowDirectionVertical byte
+
+const (
+	AirFlowDirectionVerticalUppermost    AirFlowDirectionVertical = 0x41
+	AirFlowDirectionVerticalUpperCentral AirFlowDirectionVertical = 0x44
+	AirFlowDirectionVerticalCentral      AirFlowDirectionVertical = 0x43
+	AirFlowDirectionVerticalLowerCentral AirFlowDirectionVertical = 0x45
+	AirFlowDirectionVerticalLowermost    AirFlowDirectionVertical = 0x42
+)
+
+// SpecialState is a value of Special state (0xaa).
+type SpecialState byte
+
+const (
+	SpecialStateNormal      SpecialState = 0x40
+	SpecialStateDefrosting  SpecialState = 0x41
+	SpecialStatePreheating  SpecialState = 0x42
+	SpecialStateHeatRemoval SpecialState = 0x43
+)
+
+// NonPriorityState is a value of Non-priority state (0xab).
+type NonPriorityState byte
+
+const (
+	NonPriorityStateNormal      NonPriorityState = 0x40
+	NonPriorityStateNonPriority NonPriorityState = 0x41
+)
+
+// AutomaticTemperatureControl is a value of Automatic temperature control setting (0xb1).
+type AutomaticTemperatureControl byte
+
+const (
+	AutomaticTemperatureControlAuto    AutomaticTemperatureControl = 0x41
+	AutomaticTemperatureControlNonAuto AutomaticTemperatureControl = 0x42
+)
+
+// HighSpeedOperation is a value of Normal/high-speed/silent operation setting (0xb2).
+type HighSpeedOperation byte
+
+const (
+	HighSpeedOperationNormal    HighSpeedOperation = 0x41
+	HighSpeedOperationHighSpeed HighSpeedOperation = 0x42
+	HighSpeedOperationSilent    HighSpeedOperation = 0x43
+)
+
+// VentilationMode is a value of Ventilation function setting (0xc0).
+type VentilationMode byte
+
+const (
+	VentilationModeOnOutlet VentilationMode = 0x41
+	VentilationModeOff      VentilationMode = 0x42
+	VentilationModeOnIntake VentilationMode = 0x43
+)
+
+// MountedAirCleaningMethod is a value of Mounted air cleaning method (0xc6).
+type MountedAirCleaningMethod struct {
+	ElectricalDustCollection bool
+	ClusterIon               bool
+}
+
+// MountedAirRefreshMethod is a value of Mounted air refreshing method (0xc8).
+type MountedAirRefreshMethod struct {
+	MinusIon   bool
+	ClusterIon bool
+}
+
+// MountedSelfCleaningMethod is a value of Mounted self-cleaning method (0xca).
+type MountedSelfCleaningMethod struct {
+	OzoneCleaning bool
+	Drying        bool
+}
+
+// SpecialFunction is a value of Special function setting (0xcc).
+type SpecialFunction byte
+
+const (
+	SpecialFunctionNoSetting              SpecialFunction = 0x40
+	SpecialFunctionClothesDryer           SpecialFunction = 0x41
+	SpecialFunctionCondensationSuppressor SpecialFunction = 0x42
+	SpecialFunctionMiteAndMoldControl     SpecialFunction = 0x43
+	SpecialFunctionActiveDefrosting       SpecialFunction = 0x44
+)
+
+// ComponentsOperationStatus is a value of Operation status of components (0xcd).
+type ComponentsOperationStatus struct {
+	Compressor bool
+	Thermostat bool
+}
+
+// ThermostatOverride is a value of Thermostat setting override function (0xce).
+type ThermostatOverride byte
+
+const (
+	ThermostatOverrideNormal ThermostatOverride = 0x40
+	ThermostatOverrideOn     ThermostatOverride = 0x41
+	ThermostatOverrideOff    ThermostatOverride = 0x42
+)
+
+// PowerSaving requests Power-saving operation setting (0x8f).
+func (r QueryRequest) PowerSaving() QueryRequest {
+	return r.AddEpc(EpcPowerSaving)
+}
+
+// OnTimerReservation requests ON timer-based reservation setting (0x90).
+func (r QueryRequest) OnTimerReservation() QueryRequest {
+	return r.AddEpc(EpcOnTimerReservation)
+}
+
+// OnTimerTime requests ON timer setting (time) (0x91).
+func (r QueryRequest) OnTimerTime() QueryRequest {
+	return r.AddEpc(EpcOnTimerTime)
+}
+
+// OnTimerRelativeTime requests ON timer setting (relative time) (0x92).
+func (r QueryRequest) OnTimerRelativeTime() QueryRequest {
+	return r.AddEpc(EpcOnTimerRelativeTime)
+}
+
+// OffTimerReservation requests OFF timer-based reservation setting (0x94).
+func (r QueryRequest) OffTimerReservation() QueryRequest {
+	return r.AddEpc(EpcOffTimerReservation)
+}
+
+// OffTimerTime requests OFF timer setting (time) (0x95).
+func (r QueryRequest) OffTimerTime() QueryRequest {
+	return r.AddEpc(EpcOffTimerTime)
+}
+
+// OffTimerRelativeTime requests OFF timer setting (relative time) (0x96).
+func (r QueryRequest) OffTimerRelativeTime() QueryRequest {
+	return r.AddEpc(EpcOffTimerRelativeTime)
+}
+
+// AutomaticControlAirFlowDirection requests Automatic control of air flow direction setting (0xa1).
+func (r QueryRequest) AutomaticControlAirFlowDirection() QueryRequest {
+	return r.AddEpc(EpcAutomaticControlAirFlowDirection)
+}
+
+// AutomaticSwingAirFlow requests Automatic swing of air flow setting (0xa3).
+func (r QueryRequest) AutomaticSwingAirFlow() QueryRequest {
+	return r.AddEpc(EpcAutomaticSwingAirFlow)
+}
+
+// AirFlowDirectionVertical requests Air flow direction (vertical) setting (0xa4).
+func (r QueryRequest) AirFlowDirectionVertical() QueryRequest {
+	return r.AddEpc(EpcAirFlowDirectionVertical)
+}
+
+// AirFlowDirectionHorizontal requests Air flow direction (horizontal) setting (0xa5).
+func (r QueryRequest) AirFlowDirectionHorizontal() QueryRequest {
+	return r.AddEpc(EpcAirFlowDirectionHorizontal)
+}
+
+// SpecialState requests Special state (0xaa).
+func (r QueryRequest) SpecialState() QueryRequest {
+	return r.AddEpc(EpcSpecialState)
+}
+
+// NonPriorityState requests Non-priority state (0xab).
+func (r QueryRequest) NonPriorityState() QueryRequest {
+	return r.AddEpc(EpcNonPriorityState)
+}
+
+// AutomaticTemperatureControl requests Automatic temperature control setting (0xb1).
+func (r QueryRequest) AutomaticTemperatureControl() QueryRequest {
+	return r.AddEpc(EpcAutomaticTemperatureControl)
+}
+
+// HighSpeedOperation requests Normal/high-speed/silent operation setting (0xb2).
+func (r QueryRequest) HighSpeedOperation() QueryRequest {
+	return r.AddEpc(EpcHighSpeedOperation)
+}
+
+// TargetTemperatureInCoolingMode requests Set temperature value in cooling mode (0xb5).
+func (r QueryRequest) TargetTemperatureInCoolingMode() QueryRequest {
+	return r.AddEpc(EpcTargetTemperatureInCoolingMode)
+}
+
+// TargetTemperatureInHeatingMode requests Set temperature value in heating mode (0xb6).
+func (r QueryRequest) TargetTemperatureInHeatingMode() QueryRequest {
+	return r.AddEpc(EpcTargetTemperatureInHeatingMode)
+}
+
+// TargetTemperatureInDehumidifyingMode requests Set temperature value in dehumidifying mode (0xb7).
+func (r QueryRequest) TargetTemperatureInDehumidifyingMode() QueryRequest {
+	return r.AddEpc(EpcTargetTemperatureInDehumidifyingMode)
+}
+
+// RatedPowerConsumption requests Rated power consumption (0xb8).
+func (r QueryRequest) RatedPowerConsumption() QueryRequest {
+	return r.AddEpc(EpcRatedPowerConsumption)
+}
+
+// CurrentConsumption requests Measured value of current consumption (0xb9).
+func (r QueryRequest) CurrentConsumption() QueryRequest {
+	return r.AddEpc(EpcCurrentConsumption)
+}
+
+// UserRemoteControlSettingTemperature requests Set temperature value of user remote control (0xbc).
+func (r QueryRequest) UserRemoteControlSettingTemperature() QueryRequest {
+	return r.AddEpc(EpcUserRemoteControlSettingTemperature)
+}
+
+// AirTemperature requests Measured cooled air temperature (0xbd).
+func (r QueryRequest) AirTemperature() QueryRequest {
+	return r.AddEpc(EpcAirTemperature)
+}
+
+// RelativeTemperature requests Relative temperature setting (0xbf).
+func (r QueryRequest) RelativeTemperature() QueryRequest {
+	return r.AddEpc(EpcRelativeTemperature)
+}
+
+// VentilationMode requests Ventilation function setting (0xc0).
+func (r QueryRequest) VentilationMode() QueryRequest {
+	return r.AddEpc(EpcVentilationMode)
+}
+
+// HumidifierFunction requests Humidifier function setting (0xc1).
+func (r QueryRequest) HumidifierFunction() QueryRequest {
+	return r.AddEpc(EpcHumidifierFunction)
+}
+
+// VentilationAirFlowLevel requests Ventilation air flow rate setting (0xc2).
+func (r QueryRequest) VentilationAirFlowLevel() QueryRequest {
+	return r.AddEpc(EpcVentilationAirFlowLevel)
+}
+
+// HumidificationLevel requests Degree of humidification setting (0xc4).
+func (r QueryRequest) HumidificationLevel() QueryRequest {
+	return r.AddEpc(EpcHumidificationLevel)
+}
+
+// MountedAirCleaningMethod requests Mounted air cleaning method (0xc6).
+func (r QueryRequest) MountedAirCleaningMethod() QueryRequest {
+	return r.AddEpc(EpcMountedAirCleaningMethod)
+}
+
+// AirPurifierFunction requests Air purifier function setting (0xc7).
+func (r QueryRequest) AirPurifierFunction() QueryRequest {
+	return r.AddEpc(EpcAirPurifierFunction)
+}
+
+// MountedAirRefreshMethod requests Mounted air refreshing method (0xc8).
+func (r QueryRequest) MountedAirRefreshMethod() QueryRequest {
+	return r.AddEpc(EpcMountedAirRefreshMethod)
+}
+
+// AirRefresherFunction requests Air refresher function setting (0xc9).
+func (r QueryRequest) AirRefresherFunction() QueryRequest {
+	return r.AddEpc(EpcAirRefresherFunction)
+}
+
+// MountedSelfCleaningMethod requests Mounted self-cleaning method (0xca).
+func (r QueryRequest) MountedSelfCleaningMethod() QueryRequest {
+	return r.AddEpc(EpcMountedSelfCleaningMethod)
+}
+
+// SelfCleaningFunction requests Self-cleaning function setting (0xcb).
+func (r QueryRequest) SelfCleaningFunction() QueryRequest {
+	return r.AddEpc(EpcSelfCleaningFunction)
+}
+
+// SpecialFunction requests Special function setting (0xcc).
+func (r QueryRequest) SpecialFunction() QueryRequest {
+	return r.AddEpc(EpcSpecialFunction)
+}
+
+// ComponentsOperationStatus requests Operation status of components (0xcd).
+func (r QueryRequest) ComponentsOperationStatus() QueryRequest {
+	return r.AddEpc(EpcComponentsOperationStatus)
+}
+
+// ThermostatOverride requests Thermostat setting override function (0xce).
+func (r QueryRequest) ThermostatOverride() QueryRequest {
+	return r.AddEpc(EpcThermostatOverride)
+}
+
+// AirPurificationMode requests Air purification mode setting (0xcf).
+func (r QueryRequest) AirPurificationMode() QueryRequest {
+	return r.AddEpc(EpcAirPurificationMode)
+}
+
+func (q QueryResponse) PowerSaving() (PowerSaving, error) {
+	data, err := q.FixedEdt(EpcPowerSaving, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := PowerSaving(data[0]); value {
+	case PowerSavingPowerSaving, PowerSavingNormal:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) OnTimerReservation() (bool, error) {
+	data, err := q.FixedEdt(EpcOnTimerReservation, 1)
+	if err != nil {
+		return false, err
+	}
+
+	switch data[0] {
+	case 0x41:
+		return true, nil
+	case 0x42:
+		return false, nil
+	default:
+		return false, ErrUnexpectedValue
+	}
+}
+
+// OnTimerTime returns ON timer setting (time) as a duration from midnight.
+func (q QueryResponse) OnTimerTime() (time.Duration, error) {
+	data, err := q.FixedEdt(EpcOnTimerTime, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	if data[0] > 23 || data[1] > 59 {
+		return 0, ErrUnsupportedValue
+	}
+	return time.Duration(data[0])*time.Hour + time.Duration(data[1])*time.Minute, nil
+}
+
+// OnTimerRelativeTime returns ON timer setting (relative time) as a duration from now.
+func (q QueryResponse) OnTimerRelativeTime() (time.Duration, error) {
+	data, err := q.FixedEdt(EpcOnTimerRelativeTime, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	if data[1] > 59 {
+		return 0, ErrUnsupportedValue
+	}
+	return time.Duration(data[0])*time.Hour + time.Duration(data[1])*time.Minute, nil
+}
+
+func (q QueryResponse) OffTimerReservation() (bool, error) {
+	data, err := q.FixedEdt(EpcOffTimerReservation, 1)
+	if err != nil {
+		return false, err
+	}
+
+	switch data[0] {
+	case 0x41:
+		return true, nil
+	case 0x42:
+		return false, nil
+	default:
+		return false, ErrUnexpectedValue
+	}
+}
+
+// OffTimerTime returns OFF timer setting (time) as a duration from midnight.
+func (q QueryResponse) OffTimerTime() (time.Duration, error) {
+	data, err := q.FixedEdt(EpcOffTimerTime, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	if data[0] > 23 || data[1] > 59 {
+		return 0, ErrUnsupportedValue
+	}
+	return time.Duration(data[0])*time.Hour + time.Duration(data[1])*time.Minute, nil
+}
+
+// OffTimerRelativeTime returns OFF timer setting (relative time) as a duration from now.
+func (q QueryResponse) OffTimerRelativeTime() (time.Duration, error) {
+	data, err := q.FixedEdt(EpcOffTimerRelativeTime, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	if data[1] > 59 {
+		return 0, ErrUnsupportedValue
+	}
+	return time.Duration(data[0])*time.Hour + time.Duration(data[1])*time.Minute, nil
+}
+
+func (q QueryResponse) AutomaticControlAirFlowDirection() (AutomaticControlAirFlowDirection, error) {
+	data, err := q.FixedEdt(EpcAutomaticControlAirFlowDirection, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := AutomaticControlAirFlowDirection(data[0]); value {
+	case AutomaticControlAirFlowDirectionAuto, AutomaticControlAirFlowDirectionNonAuto, AutomaticControlAirFlowDirectionAutoVertical, AutomaticControlAirFlowDirectionAutoHorizontal:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) AutomaticSwingAirFlow() (AutomaticSwingAirFlow, error) {
+	data, err := q.FixedEdt(EpcAutomaticSwingAirFlow, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := AutomaticSwingAirFlow(data[0]); value {
+	case AutomaticSwingAirFlowOff, AutomaticSwingAirFlowVertical, AutomaticSwingAirFlowHorizontal, AutomaticSwingAirFlowVerticalAndHorizontal:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) AirFlowDirectionVertical() (AirFlowDirectionVertical, error) {
+	data, err := q.FixedEdt(EpcAirFlowDirectionVertical, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := AirFlowDirectionVertical(data[0]); value {
+	case AirFlowDirectionVerticalUppermost, AirFlowDirectionVerticalUpperCentral, AirFlowDirectionVerticalCentral, AirFlowDirectionVerticalLowerCentral, AirFlowDirectionVerticalLowermost:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) AirFlowDirectionHorizontal() ([]byte, error) {
+	data, err := q.FixedEdt(EpcAirFlowDirectionHorizontal, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, data...), nil
+}
+
+func (q QueryResponse) SpecialState() (SpecialState, error) {
+	data, err := q.FixedEdt(EpcSpecialState, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := SpecialState(data[0]); value {
+	case SpecialStateNormal, SpecialStateDefrosting, SpecialStatePreheating, SpecialStateHeatRemoval:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) NonPriorityState() (NonPriorityState, error) {
+	data, err := q.FixedEdt(EpcNonPriorityState, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := NonPriorityState(data[0]); value {
+	case NonPriorityStateNormal, NonPriorityStateNonPriority:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) AutomaticTemperatureControl() (AutomaticTemperatureControl, error) {
+	data, err := q.FixedEdt(EpcAutomaticTemperatureControl, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := AutomaticTemperatureControl(data[0]); value {
+	case AutomaticTemperatureControlAuto, AutomaticTemperatureControlNonAuto:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) HighSpeedOperation() (HighSpeedOperation, error) {
+	data, err := q.FixedEdt(EpcHighSpeedOperation, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := HighSpeedOperation(data[0]); value {
+	case HighSpeedOperationNormal, HighSpeedOperationHighSpeed, HighSpeedOperationSilent:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+// TargetTemperatureInCoolingMode returns Set temperature value in cooling mode in Celsius.
+func (q QueryResponse) TargetTemperatureInCoolingMode() (int, error) {
+	data, err := q.FixedEdt(EpcTargetTemperatureInCoolingMode, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(data[0])
+	if raw > 50 {
+		return 0, ErrUnsupportedValue
+	}
+	return raw, nil
+}
+
+// TargetTemperatureInHeatingMode returns Set temperature value in heating mode in Celsius.
+func (q QueryResponse) TargetTemperatureInHeatingMode() (int, error) {
+	data, err := q.FixedEdt(EpcTargetTemperatureInHeatingMode, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(data[0])
+	if raw > 50 {
+		return 0, ErrUnsupportedValue
+	}
+	return raw, nil
+}
+
+// TargetTemperatureInDehumidifyingMode returns Set temperature value in dehumidifying mode in Celsius.
+func (q QueryResponse) TargetTemperatureInDehumidifyingMode() (int, error) {
+	data, err := q.FixedEdt(EpcTargetTemperatureInDehumidifyingMode, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(data[0])
+	if raw > 50 {
+		return 0, ErrUnsupportedValue
+	}
+	return raw, nil
+}
+
+func (q QueryResponse) RatedPowerConsumption() (mra.Value, error) {
+	data, err := q.Edt(EpcRatedPowerConsumption)
+	if err != nil {
+		return nil, err
+	}
+
+	return mra.Default().Decode(q.Object, EpcRatedPowerConsumption, data)
+}
+
+// CurrentConsumption returns Measured value of current consumption in A.
+func (q QueryResponse) CurrentConsumption() (float64, error) {
+	data, err := q.FixedEdt(EpcCurrentConsumption, 2)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(binary.BigEndian.Uint16(data))
+	if raw > 65533 {
+		return 0, ErrUnsupportedValue
+	}
+	return float64(raw) / 10, nil
+}
+
+// UserRemoteControlSettingTemperature returns Set temperature value of user remote control in Celsius.
+func (q QueryResponse) UserRemoteControlSettingTemperature() (int, error) {
+	data, err := q.FixedEdt(EpcUserRemoteControlSettingTemperature, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(data[0])
+	if raw > 50 {
+		return 0, ErrUnsupportedValue
+	}
+	return raw, nil
+}
+
+// AirTemperature returns Measured cooled air temperature in Celsius.
+func (q QueryResponse) AirTemperature() (int, error) {
+	data, err := q.FixedEdt(EpcAirTemperature, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(int8(data[0]))
+	if raw < -127 || raw > 125 {
+		return 0, ErrUnsupportedValue
+	}
+	return raw, nil
+}
+
+// RelativeTemperature returns Relative temperature setting in Celsius.
+func (q QueryResponse) RelativeTemperature() (float64, error) {
+	data, err := q.FixedEdt(EpcRelativeTemperature, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	raw := int(int8(data[0]))
+	if raw < -127 || raw > 125 {
+		return 0, ErrUnsupportedValue
+	}
+	return float64(raw) / 10, nil
+}
+
+func (q QueryResponse) VentilationMode() (VentilationMode, error) {
+	data, err := q.FixedEdt(EpcVentilationMode, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := VentilationMode(data[0]); value {
+	case VentilationModeOnOutlet, VentilationModeOff, VentilationModeOnIntake:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) HumidifierFunction() (bool, error) {
+	data, err := q.FixedEdt(EpcHumidifierFunction, 1)
+	if err != nil {
+		return false, err
+	}
+
+	switch data[0] {
+	case 0x41:
+		return true, nil
+	case 0x42:
+		return false, nil
+	default:
+		return false, ErrUnexpectedValue
+	}
+}
+
+// VentilationAirFlowLevel returns the level from 1 to 8, or true if it is automatic.
+func (q QueryResponse) VentilationAirFlowLevel() (int, bool, error) {
+	data, err := q.FixedEdt(EpcVentilationAirFlowLevel, 1)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if data[0] == 0x41 {
+		return 0, true, nil
+	}
+	if data[0] < 0x31 || data[0] > 0x38 {
+		return 0, false, ErrUnsupportedValue
+	}
+	return int(data[0]-0x31) + 1, false, nil
+}
+
+// HumidificationLevel returns the level from 1 to 8, or true if it is automatic.
+func (q QueryResponse) HumidificationLevel() (int, bool, error) {
+	data, err := q.FixedEdt(EpcHumidificationLevel, 1)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if data[0] == 0x41 {
+		return 0, true, nil
+	}
+	if data[0] < 0x31 || data[0] > 0x38 {
+		return 0, false, ErrUnsupportedValue
+	}
+	return int(data[0]-0x31) + 1, false, nil
+}
+
+func (q QueryResponse) MountedAirCleaningMethod() (MountedAirCleaningMethod, error) {
+	data, err := q.FixedEdt(EpcMountedAirCleaningMethod, 1)
+	if err != nil {
+		return MountedAirCleaningMethod{}, err
+	}
+
+	return MountedAirCleaningMethod{
+		ElectricalDustCollection: data[0]&0x01 != 0,
+		ClusterIon:               data[0]&0x02 != 0,
+	}, nil
+}
+
+func (q QueryResponse) AirPurifierFunction() ([]byte, error) {
+	data, err := q.FixedEdt(EpcAirPurifierFunction, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, data...), nil
+}
+
+func (q QueryResponse) MountedAirRefreshMethod() (MountedAirRefreshMethod, error) {
+	data, err := q.FixedEdt(EpcMountedAirRefreshMethod, 1)
+	if err != nil {
+		return MountedAirRefreshMethod{}, err
+	}
+
+	return MountedAirRefreshMethod{
+		MinusIon:   data[0]&0x01 != 0,
+		ClusterIon: data[0]&0x02 != 0,
+	}, nil
+}
+
+func (q QueryResponse) AirRefresherFunction() ([]byte, error) {
+	data, err := q.FixedEdt(EpcAirRefresherFunction, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, data...), nil
+}
+
+func (q QueryResponse) MountedSelfCleaningMethod() (MountedSelfCleaningMethod, error) {
+	data, err := q.FixedEdt(EpcMountedSelfCleaningMethod, 1)
+	if err != nil {
+		return MountedSelfCleaningMethod{}, err
+	}
+
+	return MountedSelfCleaningMethod{
+		OzoneCleaning: data[0]&0x01 != 0,
+		Drying:        data[0]&0x02 != 0,
+	}, nil
+}
+
+func (q QueryResponse) SelfCleaningFunction() ([]byte, error) {
+	data, err := q.FixedEdt(EpcSelfCleaningFunction, 8)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{}, data...), nil
+}
+
+func (q QueryResponse) SpecialFunction() (SpecialFunction, error) {
+	data, err := q.FixedEdt(EpcSpecialFunction, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := SpecialFunction(data[0]); value {
+	case SpecialFunctionNoSetting, SpecialFunctionClothesDryer, SpecialFunctionCondensationSuppressor, SpecialFunctionMiteAndMoldControl, SpecialFunctionActiveDefrosting:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) ComponentsOperationStatus() (ComponentsOperationStatus, error) {
+	data, err := q.FixedEdt(EpcComponentsOperationStatus, 1)
+	if err != nil {
+		return ComponentsOperationStatus{}, err
+	}
+
+	return ComponentsOperationStatus{
+		Compressor: data[0]&0x01 != 0,
+		Thermostat: data[0]&0x02 != 0,
+	}, nil
+}
+
+func (q QueryResponse) ThermostatOverride() (ThermostatOverride, error) {
+	data, err := q.FixedEdt(EpcThermostatOverride, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value := ThermostatOverride(data[0]); value {
+	case ThermostatOverrideNormal, ThermostatOverrideOn, ThermostatOverrideOff:
+		return value, nil
+	default:
+		return 0, ErrUnsupportedValue
+	}
+}
+
+func (q QueryResponse) AirPurificationMode() (bool, error) {
+	data, err := q.FixedEdt(EpcAirPurificationMode, 1)
+	if err != nil {
+		return false, err
+	}
+
+	switch data[0] {
+	case 0x41:
+		return true, nil
+	case 0x42:
+		return false, nil
+	default:
+		return false, ErrUnexpectedValue
+	}
+}
+
+func EncodePowerSaving(value PowerSaving) ([]byte, error) {
+	switch value {
+	case PowerSavingPowerSaving, PowerSavingNormal:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeOnTimerReservation(value bool) ([]byte, error) {
+	if value {
+		return []byte{0x41}, nil
+	}
+	return []byte{0x42}, nil
+}
+
+func EncodeOnTimerTime(value time.Duration) ([]byte, error) {
+	if value < 0 || value >= 24*time.Hour || value%time.Minute != 0 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(value / time.Hour), byte(value % time.Hour / time.Minute)}, nil
+}
+
+func EncodeOnTimerRelativeTime(value time.Duration) ([]byte, error) {
+	if value < 0 || value >= 256*time.Hour || value%time.Minute != 0 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(value / time.Hour), byte(value % time.Hour / time.Minute)}, nil
+}
+
+func EncodeOffTimerReservation(value bool) ([]byte, error) {
+	if value {
+		return []byte{0x41}, nil
+	}
+	return []byte{0x42}, nil
+}
+
+func EncodeOffTimerTime(value time.Duration) ([]byte, error) {
+	if value < 0 || value >= 24*time.Hour || value%time.Minute != 0 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(value / time.Hour), byte(value % time.Hour / time.Minute)}, nil
+}
+
+func EncodeOffTimerRelativeTime(value time.Duration) ([]byte, error) {
+	if value < 0 || value >= 256*time.Hour || value%time.Minute != 0 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(value / time.Hour), byte(value % time.Hour / time.Minute)}, nil
+}
+
+func EncodeAutomaticControlAirFlowDirection(value AutomaticControlAirFlowDirection) ([]byte, error) {
+	switch value {
+	case AutomaticControlAirFlowDirectionAuto, AutomaticControlAirFlowDirectionNonAuto, AutomaticControlAirFlowDirectionAutoVertical, AutomaticControlAirFlowDirectionAutoHorizontal:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeAutomaticSwingAirFlow(value AutomaticSwingAirFlow) ([]byte, error) {
+	switch value {
+	case AutomaticSwingAirFlowOff, AutomaticSwingAirFlowVertical, AutomaticSwingAirFlowHorizontal, AutomaticSwingAirFlowVerticalAndHorizontal:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeAirFlowDirectionVertical(value AirFlowDirectionVertical) ([]byte, error) {
+	switch value {
+	case AirFlowDirectionVerticalUppermost, AirFlowDirectionVerticalUpperCentral, AirFlowDirectionVerticalCentral, AirFlowDirectionVerticalLowerCentral, AirFlowDirectionVerticalLowermost:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeAirFlowDirectionHorizontal(value []byte) ([]byte, error) {
+	if len(value) != 1 {
+		return nil, ErrUnsupportedValue
+	}
+	return append([]byte{}, value...), nil
+}
+
+func EncodeAutomaticTemperatureControl(value AutomaticTemperatureControl) ([]byte, error) {
+	switch value {
+	case AutomaticTemperatureControlAuto, AutomaticTemperatureControlNonAuto:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeHighSpeedOperation(value HighSpeedOperation) ([]byte, error) {
+	switch value {
+	case HighSpeedOperationNormal, HighSpeedOperationHighSpeed, HighSpeedOperationSilent:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeTargetTemperatureInCoolingMode(value int) ([]byte, error) {
+	raw := value
+	if raw < 0 || raw > 50 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(raw)}, nil
+}
+
+func EncodeTargetTemperatureInHeatingMode(value int) ([]byte, error) {
+	raw := value
+	if raw < 0 || raw > 50 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(raw)}, nil
+}
+
+func EncodeTargetTemperatureInDehumidifyingMode(value int) ([]byte, error) {
+	raw := value
+	if raw < 0 || raw > 50 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(raw)}, nil
+}
+
+func EncodeRelativeTemperature(value float64) ([]byte, error) {
+	raw := int(math.Round(value * 10))
+	if raw < -127 || raw > 125 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(raw)}, nil
+}
+
+func EncodeVentilationMode(value VentilationMode) ([]byte, error) {
+	switch value {
+	case VentilationModeOnOutlet, VentilationModeOff, VentilationModeOnIntake:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeHumidifierFunction(value bool) ([]byte, error) {
+	if value {
+		return []byte{0x41}, nil
+	}
+	return []byte{0x42}, nil
+}
+
+func EncodeVentilationAirFlowLevel(level int, auto bool) ([]byte, error) {
+	if auto {
+		return []byte{0x41}, nil
+	}
+	if level < 1 || level > 8 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(0x31 + level - 1)}, nil
+}
+
+func EncodeHumidificationLevel(level int, auto bool) ([]byte, error) {
+	if auto {
+		return []byte{0x41}, nil
+	}
+	if level < 1 || level > 8 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(0x31 + level - 1)}, nil
+}
+
+func EncodeAirPurifierFunction(value []byte) ([]byte, error) {
+	if len(value) != 8 {
+		return nil, ErrUnsupportedValue
+	}
+	return append([]byte{}, value...), nil
+}
+
+func EncodeAirRefresherFunction(value []byte) ([]byte, error) {
+	if len(value) != 8 {
+		return nil, ErrUnsupportedValue
+	}
+	return append([]byte{}, value...), nil
+}
+
+func EncodeSelfCleaningFunction(value []byte) ([]byte, error) {
+	if len(value) != 8 {
+		return nil, ErrUnsupportedValue
+	}
+	return append([]byte{}, value...), nil
+}
+
+func EncodeSpecialFunction(value SpecialFunction) ([]byte, error) {
+	switch value {
+	case SpecialFunctionNoSetting, SpecialFunctionClothesDryer, SpecialFunctionCondensationSuppressor, SpecialFunctionMiteAndMoldControl, SpecialFunctionActiveDefrosting:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeThermostatOverride(value ThermostatOverride) ([]byte, error) {
+	switch value {
+	case ThermostatOverrideNormal, ThermostatOverrideOn, ThermostatOverrideOff:
+		return []byte{byte(value)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeAirPurificationMode(value bool) ([]byte, error) {
+	if value {
+		return []byte{0x41}, nil
+	}
+	return []byte{0x42}, nil
+}
@@ -0,0 +1,249 @@
+package daikin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrNoCommands = errors.New("no properties to set")
+	// ErrPropertyRejected is returned for a property which the device
+	// rejected in a SetC_SNA response.
+	ErrPropertyRejected = errors.New("property rejected")
+)
+
+// CommandRequest sets properties of an air conditioner with SetC. Values are
+// validated when they are added, and Send returns the first invalid one
+// without sending anything.
+type CommandRequest struct {
+	daikin  *Daikin
+	address net.UDPAddr
+	object  echonetlite.EOJ
+	props   map[byte][]byte
+	retry   echonetlite.RetryPolicy
+	timeout time.Duration
+	err     error
+}
+
+func (d *Daikin) Command(addr net.UDPAddr) CommandRequest {
+	return CommandRequest{
+		daikin:  d,
+		address: addr,
+		object:  ObjectAircon,
+		props:   map[byte][]byte{},
+		timeout: EchonetLiteTimeout,
+	}
+}
+
+func (r CommandRequest) Send() (CommandResponse, error) {
+	return r.SendContext(context.Background())
+}
+
+// SendContext sets the properties and waits for the response until ctx is
+// done or the timeout expires. A property which the device rejected is
+// reported by CommandResponse.Err instead of an error.
+func (r CommandRequest) SendContext(ctx context.Context) (CommandResponse, error) {
+	if r.err != nil {
+		return CommandResponse{}, r.err
+	}
+	if len(r.props) == 0 {
+		return CommandResponse{}, ErrNoCommands
+	}
+
+	epcs := maps.Keys(r.props)
+	slices.Sort(epcs)
+	f := r.daikin.controller.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj:       ObjectController,
+		Deoj:       r.object,
+		Esv:        echonetlite.ServiceTypeSetC,
+		Properties: []echonetlite.Property{},
+	}
+	for _, epc := range epcs {
+		f.Edata.Properties = append(f.Edata.Properties, echonetlite.Property{Epc: epc, Edt: r.props[epc]})
+	}
+
+	res, err := r.daikin.controller.QueryBuilder().SetTimeout(r.timeout).SetAddress(r.address).SetRetry(r.retry).SetContext(ctx, f)
+	if err != nil {
+		return CommandResponse{}, err
+	}
+	return newCommandResponse(res, epcs), nil
+}
+
+// SetInstance sends the request to the air conditioner object with the
+// instance code instead of the first one.
+func (r CommandRequest) SetInstance(instanceCode byte) CommandRequest {
+	r.object = ObjectAircon.WithInstance(instanceCode)
+	return r
+}
+
+// SetAddress sends the request to the air conditioner at addr instead of
+// the one passed to Daikin.Command.
+func (r CommandRequest) SetAddress(addr net.UDPAddr) CommandRequest {
+	r.address = addr
+	return r
+}
+
+// SetRetry sends the request again according to policy if the response is
+// lost. The timeout applies to each attempt.
+func (r CommandRequest) SetRetry(policy echonetlite.RetryPolicy) CommandRequest {
+	r.retry = policy
+	return r
+}
+
+// SetTimeout sets how long each attempt waits for the response instead of
+// EchonetLiteTimeout. If it is not positive, the deadline of the context
+// passed to SendContext is used.
+func (r CommandRequest) SetTimeout(timeout time.Duration) CommandRequest {
+	r.timeout = timeout
+	return r
+}
+
+// AddProperty sets epc to edt, e.g. a value encoded by EncodePowerSaving.
+// A previous value of epc is replaced. Requests which r was derived from
+// are not affected.
+func (r CommandRequest) AddProperty(epc byte, edt []byte) CommandRequest {
+	r.props = maps.Clone(r.props)
+	r.props[epc] = slices.Clone(edt)
+	return r
+}
+
+func (r CommandRequest) add(epc byte, edt []byte, err error) CommandRequest {
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("epc 0x%02x: %w", epc, err)
+		}
+		return r
+	}
+	return r.AddProperty(epc, edt)
+}
+
+func (r CommandRequest) Power(on bool) CommandRequest {
+	edt, err := EncodeOperationStatus(on)
+	return r.add(EpcOperationStatus, edt, err)
+}
+
+func (r CommandRequest) Mode(mode OperationMode) CommandRequest {
+	edt, err := EncodeOperationMode(mode)
+	return r.add(EpcOperationMode, edt, err)
+}
+
+// Temperature sets the temperature setting in degrees Celsius (0-50).
+func (r CommandRequest) Temperature(temperature int) CommandRequest {
+	edt, err := EncodeTemperatureSetting(temperature)
+	return r.add(EpcTemperatureSetting, edt, err)
+}
+
+// Humidity sets the humidity setting in percent (0-100).
+func (r CommandRequest) Humidity(humidity int) CommandRequest {
+	edt, err := EncodeHumiditySetting(humidity)
+	return r.add(EpcHumiditySetting, edt, err)
+}
+
+// Airflow sets the airflow rate to a level (1-8).
+func (r CommandRequest) Airflow(level int) CommandRequest {
+	edt, err := EncodeAirflowRate(level, false)
+	return r.add(EpcAirflowRate, edt, err)
+}
+
+func (r CommandRequest) AirflowAuto() CommandRequest {
+	edt, err := EncodeAirflowRate(0, true)
+	return r.add(EpcAirflowRate, edt, err)
+}
+
+// CommandResponse is the result of each property of a CommandRequest.
+type CommandResponse struct {
+	Address net.UDPAddr
+	// Object is the air conditioner object which responded.
+	Object echonetlite.EOJ
+	// Accepted is true if the device accepted all properties.
+	Accepted bool
+	errs     map[byte]error
+	sent     []byte
+}
+
+func newCommandResponse(res echonetlite.SetResponse, sent []byte) CommandResponse {
+	r := CommandResponse{
+		Address: res.Addr,
+		Object:  res.Frame.Edata.Seoj,
+		errs:    map[byte]error{},
+		sent:    sent,
+	}
+	for _, epc := range res.Rejected {
+		r.errs[epc] = ErrPropertyRejected
+	}
+	for _, epc := range sent {
+		if !slices.Contains(res.Accepted, epc) && !slices.Contains(res.Rejected, epc) {
+			r.errs[epc] = ErrNoResponsesForEpc
+		}
+	}
+	r.Accepted = len(r.errs) == 0
+	return r
+}
+
+// Err returns why the device did not accept epc, or nil if it did.
+func (r CommandResponse) Err(epc byte) error {
+	if err, ok := r.errs[epc]; ok {
+		return err
+	}
+	if !slices.Contains(r.sent, epc) {
+		return ErrNoResponsesForEpc
+	}
+	return nil
+}
+
+// Errors returns errors for the properties which the device did not accept.
+func (r CommandResponse) Errors() map[byte]error {
+	return maps.Clone(r.errs)
+}
+
+func EncodeOperationStatus(on bool) ([]byte, error) {
+	if on {
+		return []byte{0x30}, nil
+	}
+	return []byte{0x31}, nil
+}
+
+// EncodeOperationMode encodes a mode to set. OperationModeOther is only a
+// state reported by devices.
+func EncodeOperationMode(mode OperationMode) ([]byte, error) {
+	switch mode {
+	case OperationModeAuto, OperationModeCooling, OperationModeHeating, OperationModeDehumidification, OperationModeVentilating:
+		return []byte{byte(mode)}, nil
+	default:
+		return nil, ErrUnsupportedValue
+	}
+}
+
+func EncodeTemperatureSetting(temperature int) ([]byte, error) {
+	if temperature < 0 || temperature > 50 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(temperature)}, nil
+}
+
+func EncodeHumiditySetting(humidity int) ([]byte, error) {
+	if humidity < 0 || humidity > 100 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(humidity)}, nil
+}
+
+// EncodeAirflowRate encodes a level (1-8), or automatic airflow if auto is
+// true.
+func EncodeAirflowRate(level int, auto bool) ([]byte, error) {
+	if auto {
+		return []byte{0x41}, nil
+	}
+	if level < 1 || level > 8 {
+		return nil, ErrUnsupportedValue
+	}
+	return []byte{byte(0x30 + level)}, nil
+}
@@ -1,9 +1,14 @@
 package daikin
 
 import (
+	"context"
+	"net"
+
 	"github.com/int2xx9/daikin-airconditioner/echonetlite"
 )
 
+//go:generate go run ../cmd/echonetgen -class 0x0130 -package daikin -request -skip 80,a0,b0,b3,b4,ba,bb,be -output aircon_gen.go
+
 const (
 	EpcOperationStatus               byte = 0x80
 	EpcIdentificationNumber          byte = 0x83
@@ -31,7 +36,12 @@ func NewDaikin(c *echonetlite.Controller) Daikin {
 
 func (d *Daikin) Request() QueryRequest {
 	return QueryRequest{
-		daikin: d,
-		epcs:   map[byte]any{},
+		req: echonetlite.NewPropertyRequest(d.controller, ObjectAircon).WithTimeout(EchonetLiteTimeout),
 	}
 }
+
+// Capabilities returns the property maps of the air conditioner object eoj
+// at addr. They are cached by the controller after the first query.
+func (d *Daikin) Capabilities(ctx context.Context, addr net.UDPAddr, eoj echonetlite.EOJ) (echonetlite.Capabilities, error) {
+	return d.controller.QueryBuilder().SetTimeout(EchonetLiteTimeout).SetAddress(addr).Capabilities(ctx, eoj)
+}
@@ -0,0 +1,472 @@
+package daikin_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/daikin"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/echonettest"
+)
+
+var (
+	controllerAddr = net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 3610}
+	airconAddr     = net.UDPAddr{IP: net.IPv4(192, 0, 2, 11), Port: 3610}
+)
+
+func startAircon(t *testing.T, network *echonetlite.MemoryNetwork, addr net.UDPAddr, unsupported ...byte) *echonetlite.Controller {
+	object := &echonetlite.LocalObject{
+		Eoj: daikin.ObjectAircon,
+		Properties: map[byte][]byte{
+			daikin.EpcOperationStatus:               {0x30},
+			daikin.EpcIdentificationNumber:          {0xfe, 0x00, 0x00, 0x08, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d},
+			daikin.EpcInstantaneousPowerConsumption: {0x01, 0x2c},
+			daikin.EpcCumulativePowerConsumption:    {0x00, 0x01, 0x00, 0x00},
+			daikin.EpcFaultStatus:                   {0x42},
+			daikin.EpcAirflowRate:                   {0x41},
+			daikin.EpcOperationMode:                 {0x42},
+			daikin.EpcTemperatureSetting:            {0x1a},
+			daikin.EpcHumiditySetting:               {0x32},
+			daikin.EpcRoomTemperature:               {0x1c},
+			daikin.EpcRoomHumidity:                  {0x3c},
+			daikin.EpcOutdoorTemperature:            {0x23},
+		},
+	}
+	for _, epc := range unsupported {
+		delete(object.Properties, epc)
+	}
+	return echonettest.StartDevice(t, network, addr, object)
+}
+
+func TestQuery(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	startAircon(t, network, airconAddr)
+	d := daikin.NewDaikin(c)
+
+	resps, err := d.Request().
+		IdentificationNumber().
+		OperationStatus().
+		InstantaneousPowerConsumption().
+		CumulativePowerConsumption().
+		FaultStatus().
+		AirflowRate().
+		OperationMode().
+		TemperatureSetting().
+		HumiditySetting().
+		RoomTemperature().
+		RoomHumidity().
+		OutdoorTemperature().
+		SetAddress(airconAddr).
+		Query()
+	if err != nil {
+		t.Fatalf("Query failure: %v", err)
+	}
+	if len(resps) != 1 {
+		t.Fatalf("Query failure: %d responses", len(resps))
+	}
+	resp := resps[0]
+	if !resp.Address.IP.Equal(airconAddr.IP) {
+		t.Errorf("Address failure: %v", resp.Address)
+	}
+
+	if id, err := resp.IdentificationNumber(); err != nil || !reflect.DeepEqual(id, []byte{0x00, 0x00, 0x08, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d}) {
+		t.Errorf("IdentificationNumber failure: %v, %v", id, err)
+	}
+	if v, err := resp.OperationStatus(); err != nil || !v {
+		t.Errorf("OperationStatus failure: %v, %v", v, err)
+	}
+	if v, err := resp.InstantaneousPowerConsumption(); err != nil || v != 300 {
+		t.Errorf("InstantaneousPowerConsumption failure: %v, %v", v, err)
+	}
+	if v, err := resp.CumulativePowerConsumption(); err != nil || v != 65536 {
+		t.Errorf("CumulativePowerConsumption failure: %v, %v", v, err)
+	}
+	if v, err := resp.FaultStatus(); err != nil || v {
+		t.Errorf("FaultStatus failure: %v, %v", v, err)
+	}
+	if v, auto, err := resp.AirflowRate(); err != nil || !auto {
+		t.Errorf("AirflowRate failure: %v, %v, %v", v, auto, err)
+	}
+	if v, err := resp.OperationMode(); err != nil || v != daikin.OperationModeCooling {
+		t.Errorf("OperationMode failure: %v, %v", v, err)
+	}
+	if v, err := resp.TemperatureSetting(); err != nil || v != 26 {
+		t.Errorf("TemperatureSetting failure: %v, %v", v, err)
+	}
+	if v, err := resp.HumiditySetting(); err != nil || v != 50 {
+		t.Errorf("HumiditySetting failure: %v, %v", v, err)
+	}
+	if v, err := resp.RoomTemperature(); err != nil || v != 28 {
+		t.Errorf("RoomTemperature failure: %v, %v", v, err)
+	}
+	if v, err := resp.RoomHumidity(); err != nil || v != 60 {
+		t.Errorf("RoomHumidity failure: %v, %v", v, err)
+	}
+	if v, err := resp.OutdoorTemperature(); err != nil || v != 35 {
+		t.Errorf("OutdoorTemperature failure: %v, %v", v, err)
+	}
+}
+
+func TestQueryMeasuredTemperature(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	aircon := startAircon(t, network, airconAddr)
+	d := daikin.NewDaikin(c)
+
+	tests := []struct {
+		edt     byte
+		expect  int
+		wantErr error
+	}{
+		{0xfb, -5, nil},
+		{0x7d, 125, nil},
+		{0x81, -127, nil},
+		{0x7e, 0, daikin.ErrUnsupportedValue},
+		{0x7f, 0, daikin.ErrUnsupportedValue},
+		{0x80, 0, daikin.ErrUnsupportedValue},
+	}
+	for _, tt := range tests {
+		for _, epc := range []byte{daikin.EpcRoomTemperature, daikin.EpcOutdoorTemperature} {
+			if err := aircon.Node.SetProperty(daikin.ObjectAircon, epc, []byte{tt.edt}); err != nil {
+				t.Fatalf("SetProperty failure: %v", err)
+			}
+		}
+		resps, err := d.Request().RoomTemperature().OutdoorTemperature().SetAddress(airconAddr).Query()
+		if err != nil || len(resps) != 1 {
+			t.Fatalf("Query failure: %v, %v", resps, err)
+		}
+		if v, err := resps[0].RoomTemperature(); v != tt.expect || err != tt.wantErr {
+			t.Errorf("RoomTemperature failure for 0x%02x: %v, %v", tt.edt, v, err)
+		}
+		if v, err := resps[0].OutdoorTemperature(); v != tt.expect || err != tt.wantErr {
+			t.Errorf("OutdoorTemperature failure for 0x%02x: %v, %v", tt.edt, v, err)
+		}
+	}
+}
+
+func TestQueryUnsupportedEpc(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	// a unit without a humidifier
+	startAircon(t, network, airconAddr, daikin.EpcHumiditySetting)
+	d := daikin.NewDaikin(c)
+
+	for name, request := range map[string]daikin.QueryRequest{
+		"Unicast":   d.Request().OperationStatus().HumiditySetting().SetAddress(airconAddr),
+		"Multicast": d.Request().OperationStatus().HumiditySetting(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			resps, err := request.Query()
+			if err != nil || len(resps) != 1 {
+				t.Fatalf("Query failure: %v, %v", resps, err)
+			}
+			if v, err := resps[0].OperationStatus(); err != nil || !v {
+				t.Errorf("OperationStatus failure: %v, %v", v, err)
+			}
+			if _, err := resps[0].HumiditySetting(); !errors.Is(err, daikin.ErrPropertyNotSupported) {
+				t.Errorf("HumiditySetting failure: %v", err)
+			}
+		})
+	}
+}
+
+// serveGet answers Get requests with properties like a device which lists all
+// properties in its Get property map but fails to read some of them.
+func serveGet(t *testing.T, transport *echonetlite.MemoryTransport, properties map[byte][]byte) {
+	for {
+		data, addr, err := transport.Receive()
+		if err != nil {
+			return
+		}
+		req, err := echonetlite.DeserializeFrame(data)
+		if err != nil {
+			t.Errorf("DeserializeFrame failure: %v", err)
+			return
+		}
+
+		res := req
+		res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+		res.Edata.Esv = echonetlite.ServiceTypeGetRes
+		res.Edata.Properties = []echonetlite.Property{}
+		for _, prop := range req.Edata.Properties {
+			edt, ok := properties[prop.Epc]
+			if !ok {
+				res.Edata.Esv = echonetlite.ServiceTypeGetSna
+				edt = []byte{}
+			}
+			res.Edata.Properties = append(res.Edata.Properties, echonetlite.Property{Epc: prop.Epc, Edt: edt})
+		}
+		if data, err := res.Serialize(); err == nil {
+			transport.Send(addr, data)
+		}
+	}
+}
+
+func TestQueryPartial(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(airconAddr)
+	t.Cleanup(func() { device.Close() })
+	go serveGet(t, device, map[byte][]byte{
+		daikin.EpcOperationStatus:    {0x30},
+		daikin.EpcTemperatureSetting: {0x1a},
+	})
+	d := daikin.NewDaikin(c)
+
+	resps, err := d.Request().
+		OperationStatus().
+		TemperatureSetting().
+		HumiditySetting().
+		SetAddress(airconAddr).
+		Query()
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	resp := resps[0]
+	if !resp.Partial {
+		t.Errorf("Partial failure")
+	}
+	if v, err := resp.OperationStatus(); err != nil || !v {
+		t.Errorf("OperationStatus failure: %v, %v", v, err)
+	}
+	if v, err := resp.TemperatureSetting(); err != nil || v != 26 {
+		t.Errorf("TemperatureSetting failure: %v, %v", v, err)
+	}
+	if _, err := resp.HumiditySetting(); !errors.Is(err, daikin.ErrPropertyNotAvailable) {
+		t.Errorf("HumiditySetting failure: %v", err)
+	}
+	if errs := resp.Errors(); len(errs) != 1 || !errors.Is(errs[daikin.EpcHumiditySetting], daikin.ErrPropertyNotAvailable) {
+		t.Errorf("Errors failure: %v", errs)
+	}
+	if err := resp.Err(daikin.EpcRoomTemperature); !errors.Is(err, daikin.ErrNoResponsesForEpc) {
+		t.Errorf("Err failure: %v", err)
+	}
+}
+
+func TestQueryInstance(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	aircon := startAircon(t, network, airconAddr)
+	aircon.Node.AddObject(&echonetlite.LocalObject{
+		Eoj: daikin.ObjectAircon.WithInstance(2),
+		Properties: map[byte][]byte{
+			daikin.EpcOperationStatus:    {0x31},
+			daikin.EpcTemperatureSetting: {0x14},
+		},
+	})
+	d := daikin.NewDaikin(c)
+
+	resps, err := d.Request().TemperatureSetting().SetInstance(2).SetAddress(airconAddr).Query()
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	if v, err := resps[0].TemperatureSetting(); resps[0].Object != 0x013002 || err != nil || v != 20 {
+		t.Errorf("TemperatureSetting failure: %v, %v, %v", resps[0].Object, v, err)
+	}
+
+	resps, err = d.Request().TemperatureSetting().SetInstance(0).SetAddress(airconAddr).Query()
+	if err != nil || len(resps) != 2 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	for _, resp := range resps {
+		expect := map[echonetlite.EOJ]int{0x013001: 26, 0x013002: 20}[resp.Object]
+		if v, err := resp.TemperatureSetting(); err != nil || v != expect {
+			t.Errorf("TemperatureSetting failure: %v, %v, %v", resp.Object, v, err)
+		}
+	}
+}
+
+func TestQueryGenerated(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	aircon := startAircon(t, network, airconAddr)
+	for epc, edt := range map[byte][]byte{
+		daikin.EpcPowerSaving:                    {0x42},
+		daikin.EpcOnTimerReservation:             {0x41},
+		daikin.EpcOnTimerTime:                    {0x07, 0x1e},
+		daikin.EpcOnTimerRelativeTime:            {0x30, 0x00},
+		daikin.EpcOffTimerTime:                   {0x18, 0x00},
+		daikin.EpcTargetTemperatureInCoolingMode: {0xfd},
+		daikin.EpcRelativeTemperature:            {0xfb},
+		daikin.EpcVentilationAirFlowLevel:        {0x41},
+		daikin.EpcComponentsOperationStatus:      {0x01},
+		daikin.EpcRatedPowerConsumption:          {0x02, 0x58, 0x03, 0x20, 0x01, 0x90, 0x00, 0x32},
+	} {
+		if err := aircon.Node.SetProperty(daikin.ObjectAircon, epc, edt); err != nil {
+			t.Fatalf("SetProperty failure: %v", err)
+		}
+	}
+	d := daikin.NewDaikin(c)
+
+	resps, err := d.Request().PowerSaving().OnTimerReservation().OnTimerTime().OnTimerRelativeTime().OffTimerTime().TargetTemperatureInCoolingMode().
+		RelativeTemperature().VentilationAirFlowLevel().ComponentsOperationStatus().RatedPowerConsumption().
+		SetAddress(airconAddr).Query()
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	resp := resps[0]
+
+	if v, err := resp.PowerSaving(); err != nil || v != daikin.PowerSavingNormal {
+		t.Errorf("PowerSaving failure: %v, %v", v, err)
+	}
+	if v, err := resp.OnTimerReservation(); err != nil || !v {
+		t.Errorf("OnTimerReservation failure: %v, %v", v, err)
+	}
+	if v, err := resp.OnTimerTime(); err != nil || v != 7*time.Hour+30*time.Minute {
+		t.Errorf("OnTimerTime failure: %v, %v", v, err)
+	}
+	// only relative times may exceed 23 hours
+	if v, err := resp.OnTimerRelativeTime(); err != nil || v != 48*time.Hour {
+		t.Errorf("OnTimerRelativeTime failure: %v, %v", v, err)
+	}
+	if v, err := resp.OffTimerTime(); err != daikin.ErrUnsupportedValue {
+		t.Errorf("OffTimerTime failure: %v, %v", v, err)
+	}
+	if v, err := resp.TargetTemperatureInCoolingMode(); err != daikin.ErrUnsupportedValue {
+		t.Errorf("TargetTemperatureInCoolingMode failure: %v, %v", v, err)
+	}
+	if v, err := resp.RelativeTemperature(); err != nil || v != -0.5 {
+		t.Errorf("RelativeTemperature failure: %v, %v", v, err)
+	}
+	if level, auto, err := resp.VentilationAirFlowLevel(); err != nil || level != 0 || !auto {
+		t.Errorf("VentilationAirFlowLevel failure: %v, %v, %v", level, auto, err)
+	}
+	if v, err := resp.ComponentsOperationStatus(); err != nil || v != (daikin.ComponentsOperationStatus{Compressor: true}) {
+		t.Errorf("ComponentsOperationStatus failure: %v, %v", v, err)
+	}
+	if v, err := resp.RatedPowerConsumption(); err != nil || v.String() != "{circulation=50W cooling=600W dehumidifying=400W heating=800W}" {
+		t.Errorf("RatedPowerConsumption failure: %v, %v", v, err)
+	}
+	if _, err := resp.AirPurificationMode(); err != daikin.ErrNoResponsesForEpc {
+		t.Errorf("AirPurificationMode failure: %v", err)
+	}
+
+	for _, tt := range []struct {
+		edt    func() ([]byte, error)
+		expect []byte
+	}{
+		{func() ([]byte, error) { return daikin.EncodePowerSaving(daikin.PowerSavingPowerSaving) }, []byte{0x41}},
+		{func() ([]byte, error) { return daikin.EncodeOnTimerTime(7*time.Hour + 30*time.Minute) }, []byte{0x07, 0x1e}},
+		{func() ([]byte, error) { return daikin.EncodeOnTimerTime(24 * time.Hour) }, nil},
+		{func() ([]byte, error) { return daikin.EncodeOnTimerRelativeTime(48 * time.Hour) }, []byte{0x30, 0x00}},
+		{func() ([]byte, error) { return daikin.EncodeRelativeTemperature(-0.5) }, []byte{0xfb}},
+		{func() ([]byte, error) { return daikin.EncodeVentilationAirFlowLevel(3, false) }, []byte{0x33}},
+		{func() ([]byte, error) { return daikin.EncodeTargetTemperatureInCoolingMode(51) }, nil},
+		{func() ([]byte, error) { return daikin.EncodePowerSaving(0x43) }, nil},
+	} {
+		edt, err := tt.edt()
+		if !reflect.DeepEqual(edt, tt.expect) || (tt.expect == nil) != (err == daikin.ErrUnsupportedValue) {
+			t.Errorf("Encode failure: %x, %v, expected %x", edt, err, tt.expect)
+		}
+	}
+}
+
+func TestCommand(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	aircon := echonettest.StartDevice(t, network, airconAddr, &echonetlite.LocalObject{
+		Eoj: daikin.ObjectAircon,
+		Properties: map[byte][]byte{
+			daikin.EpcOperationStatus:    {0x31},
+			daikin.EpcAirflowRate:        {0x33},
+			daikin.EpcOperationMode:      {0x43},
+			daikin.EpcTemperatureSetting: {0x14},
+			daikin.EpcHumiditySetting:    {0x32},
+		},
+		SetEpcs: []byte{daikin.EpcOperationStatus, daikin.EpcAirflowRate, daikin.EpcOperationMode, daikin.EpcTemperatureSetting},
+	})
+	d := daikin.NewDaikin(c)
+
+	res, err := d.Command(airconAddr).Power(true).Mode(daikin.OperationModeCooling).Temperature(26).AirflowAuto().Humidity(45).Send()
+	if err != nil {
+		t.Fatalf("Send failure: %v", err)
+	}
+	if res.Accepted || res.Object != daikin.ObjectAircon {
+		t.Errorf("Send failure: %+v", res)
+	}
+	for _, epc := range []byte{daikin.EpcOperationStatus, daikin.EpcOperationMode, daikin.EpcTemperatureSetting, daikin.EpcAirflowRate} {
+		if err := res.Err(epc); err != nil {
+			t.Errorf("Err failure for 0x%02x: %v", epc, err)
+		}
+	}
+	if err := res.Err(daikin.EpcHumiditySetting); err != daikin.ErrPropertyRejected {
+		t.Errorf("Err failure for humidity: %v", err)
+	}
+	for epc, expect := range map[byte][]byte{
+		daikin.EpcOperationStatus:    {0x30},
+		daikin.EpcOperationMode:      {0x42},
+		daikin.EpcTemperatureSetting: {0x1a},
+		daikin.EpcAirflowRate:        {0x41},
+		daikin.EpcHumiditySetting:    {0x32},
+	} {
+		if edt, _ := aircon.Node.Property(daikin.ObjectAircon, epc); !reflect.DeepEqual(edt, expect) {
+			t.Errorf("Property failure for 0x%02x: %x, expected %x", epc, edt, expect)
+		}
+	}
+
+	res, err = d.Command(airconAddr).Power(false).Airflow(5).Send()
+	if err != nil || !res.Accepted {
+		t.Errorf("Send failure: %+v, %v", res, err)
+	}
+
+	for _, r := range []daikin.CommandRequest{
+		d.Command(airconAddr).Power(true).Temperature(51),
+		d.Command(airconAddr).Mode(daikin.OperationModeOther),
+		d.Command(airconAddr).Airflow(9),
+		d.Command(airconAddr).Humidity(-1),
+	} {
+		if _, err := r.Send(); !errors.Is(err, daikin.ErrUnsupportedValue) {
+			t.Errorf("Send failure for an invalid value: %v", err)
+		}
+	}
+	if edt, _ := aircon.Node.Property(daikin.ObjectAircon, daikin.EpcOperationStatus); !reflect.DeepEqual(edt, []byte{0x31}) {
+		t.Errorf("Send failure: an invalid request was sent: %x", edt)
+	}
+	if _, err := d.Command(airconAddr).Send(); err != daikin.ErrNoCommands {
+		t.Errorf("Send failure for no properties: %v", err)
+	}
+}
+
+func TestCommandCopy(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	aircon := echonettest.StartDevice(t, network, airconAddr, &echonetlite.LocalObject{
+		Eoj: daikin.ObjectAircon,
+		Properties: map[byte][]byte{
+			daikin.EpcOperationStatus: {0x31},
+			daikin.EpcOperationMode:   {0x43},
+		},
+		SetEpcs: []byte{daikin.EpcOperationStatus, daikin.EpcOperationMode},
+	})
+	d := daikin.NewDaikin(c)
+
+	// requests derived from the same one don't share properties
+	base := d.Command(net.UDPAddr{IP: net.IPv4(192, 0, 2, 99), Port: 3610}).Power(true).SetAddress(airconAddr).SetRetry(echonetlite.DefaultRetryPolicy)
+	cooling := base.Mode(daikin.OperationModeCooling)
+	heating := base.Mode(daikin.OperationModeHeating)
+	for _, tt := range []struct {
+		r      daikin.CommandRequest
+		expect []byte
+	}{
+		{heating, []byte{0x43}},
+		{cooling, []byte{0x42}},
+	} {
+		if res, err := tt.r.SendContext(context.Background()); err != nil || !res.Accepted {
+			t.Fatalf("SendContext failure: %+v, %v", res, err)
+		}
+		if edt, _ := aircon.Node.Property(daikin.ObjectAircon, daikin.EpcOperationMode); !reflect.DeepEqual(edt, tt.expect) {
+			t.Errorf("SendContext failure: %x, expected %x", edt, tt.expect)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := d.Command(airconAddr).Power(false).SendContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("SendContext failure for a canceled context: %v", err)
+	}
+}
@@ -8,77 +8,26 @@ import (
 )
 
 const (
-	ObjectAircon     = 0x013001
-	ObjectController = 0x05ff01
+	// ObjectAircon is the first instance of home air conditioners. See
+	// QueryRequest.SetInstance for other instances.
+	ObjectAircon     echonetlite.EOJ = 0x013001
+	ObjectController echonetlite.EOJ = 0x05ff01
 )
 
 const (
 	EchonetLiteTimeout = 1 * time.Second
 )
 
-type QueryRequest struct {
-	daikin *Daikin
-	epcs   map[byte]any
-}
-
 var (
+	// Deprecated: QueryContext no longer returns ErrQueryFailed. A device
+	// which responds with Get_SNA is returned as a partial QueryResponse.
 	ErrQueryFailed = errors.New("query failed")
 )
 
-func (r QueryRequest) Query() ([]QueryResponse, error) {
-	frame := r.daikin.controller.CreateFrame()
-	frame.Edata = echonetlite.SpecifiedMessage{
-		Seoj:       ObjectController,
-		Deoj:       ObjectAircon,
-		Esv:        echonetlite.ServiceTypeGet,
-		Properties: []echonetlite.Property{},
-	}
-	for epc := range r.epcs {
-		frame.Edata.Properties = append(frame.Edata.Properties, echonetlite.Property{
-			Epc: epc,
-			Edt: []byte{},
-		})
-	}
-
-	responses, err := r.daikin.controller.QueryBuilder().SetTimeout(EchonetLiteTimeout).Query(frame)
-	if err != nil {
-		return []QueryResponse{}, err
-	}
-
-	retResponses := []QueryResponse{}
-	var lasterror error = nil
-	for _, res := range responses {
-		if res.Frame.Edata.Esv != echonetlite.ServiceTypeGetRes {
-			lasterror = ErrQueryFailed
-			continue
-		}
-
-		retResponse := QueryResponse{
-			Address: res.Addr,
-			data:    map[byte][]byte{},
-		}
-		retResponses = append(retResponses, retResponse)
-		for _, prop := range res.Frame.Edata.Properties {
-			retResponse.data[prop.Epc] = prop.Edt
-		}
-	}
-
-	return retResponses, lasterror
-}
-
-func (r QueryRequest) AddEpc(epc byte) QueryRequest {
-	r.epcs[epc] = true
-	return r
-}
-
 func (r QueryRequest) OperationStatus() QueryRequest {
 	return r.AddEpc(EpcOperationStatus)
 }
 
-func (r QueryRequest) IdentificationNumber() QueryRequest {
-	return r.AddEpc(EpcIdentificationNumber)
-}
-
 func (r QueryRequest) InstantaneousPowerConsumption() QueryRequest {
 	return r.AddEpc(EpcInstantaneousPowerConsumption)
 }
@@ -3,14 +3,6 @@ package daikin
 import (
 	"bytes"
 	"encoding/binary"
-	"errors"
-	"net"
-)
-
-var (
-	ErrNoResponsesForEpc = errors.New("no responses for epc")
-	ErrUnexpectedValue   = errors.New("unexpected value")
-	ErrUnsupportedValue  = errors.New("unsupported value")
 )
 
 type OperationMode byte
@@ -24,15 +16,10 @@ const (
 	OperationModeOther            OperationMode = 0x40
 )
 
-type QueryResponse struct {
-	Address net.UDPAddr
-	data    map[byte][]byte
-}
-
 func (q QueryResponse) OperationStatus() (bool, error) {
-	data, ok := q.data[EpcOperationStatus]
-	if !ok {
-		return false, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcOperationStatus, 1)
+	if err != nil {
+		return false, err
 	}
 
 	switch data[0] {
@@ -45,34 +32,19 @@ func (q QueryResponse) OperationStatus() (bool, error) {
 	}
 }
 
-func (q QueryResponse) IdentificationNumber() ([]byte, error) {
-	data, ok := q.data[EpcIdentificationNumber]
-	if !ok {
-		return nil, ErrNoResponsesForEpc
-	}
-
-	if data[0] != 0xfe {
-		return nil, ErrUnsupportedValue
-	}
-
-	ret := make([]byte, 16)
-	copy(ret, data[1:])
-	return ret, nil
-}
-
 func (q QueryResponse) InstantaneousPowerConsumption() (int, error) {
-	data, ok := q.data[EpcInstantaneousPowerConsumption]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcInstantaneousPowerConsumption, 2)
+	if err != nil {
+		return 0, err
 	}
 
 	return int(data[0])<<8 | int(data[1]), nil
 }
 
 func (q QueryResponse) CumulativePowerConsumption() (int, error) {
-	data, ok := q.data[EpcCumulativePowerConsumption]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcCumulativePowerConsumption, 4)
+	if err != nil {
+		return 0, err
 	}
 
 	var value uint32
@@ -83,9 +55,9 @@ func (q QueryResponse) CumulativePowerConsumption() (int, error) {
 }
 
 func (q QueryResponse) FaultStatus() (bool, error) {
-	data, ok := q.data[EpcFaultStatus]
-	if !ok {
-		return false, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcFaultStatus, 1)
+	if err != nil {
+		return false, err
 	}
 
 	switch data[0] {
@@ -99,9 +71,9 @@ func (q QueryResponse) FaultStatus() (bool, error) {
 }
 
 func (q QueryResponse) AirflowRate() (int, bool, error) {
-	data, ok := q.data[EpcAirflowRate]
-	if !ok {
-		return 0, false, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcAirflowRate, 1)
+	if err != nil {
+		return 0, false, err
 	}
 
 	if data[0] == 0x41 {
@@ -112,9 +84,9 @@ func (q QueryResponse) AirflowRate() (int, bool, error) {
 }
 
 func (q QueryResponse) OperationMode() (OperationMode, error) {
-	data, ok := q.data[EpcOperationMode]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcOperationMode, 1)
+	if err != nil {
+		return 0, err
 	}
 
 	if data[0] < 0x40 || data[0] > 0x45 {
@@ -125,46 +97,55 @@ func (q QueryResponse) OperationMode() (OperationMode, error) {
 }
 
 func (q QueryResponse) TemperatureSetting() (int, error) {
-	data, ok := q.data[EpcTemperatureSetting]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcTemperatureSetting, 1)
+	if err != nil {
+		return 0, err
 	}
 
 	return int(data[0]), nil
 }
 
 func (q QueryResponse) HumiditySetting() (int, error) {
-	data, ok := q.data[EpcHumiditySetting]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcHumiditySetting, 1)
+	if err != nil {
+		return 0, err
 	}
 
 	return int(data[0]), nil
 }
 
+// RoomTemperature returns the measured room temperature, which may be below
+// zero, or ErrUnsupportedValue for an overflow or an underflow.
 func (q QueryResponse) RoomTemperature() (int, error) {
-	data, ok := q.data[EpcRoomTemperature]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
-	}
-
-	return int(data[0]), nil
+	return q.measuredTemperature(EpcRoomTemperature)
 }
 
 func (q QueryResponse) RoomHumidity() (int, error) {
-	data, ok := q.data[EpcRoomHumidity]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	data, err := q.FixedEdt(EpcRoomHumidity, 1)
+	if err != nil {
+		return 0, err
 	}
 
 	return int(data[0]), nil
 }
 
+// OutdoorTemperature returns the measured outdoor temperature like
+// RoomTemperature.
 func (q QueryResponse) OutdoorTemperature() (int, error) {
-	data, ok := q.data[EpcOutdoorTemperature]
-	if !ok {
-		return 0, ErrNoResponsesForEpc
+	return q.measuredTemperature(EpcOutdoorTemperature)
+}
+
+// measuredTemperature decodes a signed temperature from -127 to 125. 0x7e and
+// 0x7f are overflows and 0x80 is an underflow.
+func (q QueryResponse) measuredTemperature(epc byte) (int, error) {
+	data, err := q.FixedEdt(epc, 1)
+	if err != nil {
+		return 0, err
 	}
 
-	return int(data[0]), nil
+	value := int(int8(data[0]))
+	if value < -127 || value > 125 {
+		return 0, ErrUnsupportedValue
+	}
+	return value, nil
 }
@@ -0,0 +1,207 @@
+package daikinsim
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/int2xx9/daikin-airconditioner/daikin"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// State is the initial state of an emulated air conditioner.
+type State struct {
+	OperationStatus               bool
+	IdentificationNumber          [16]byte
+	InstantaneousPowerConsumption uint16
+	CumulativePowerConsumption    uint32
+	FaultStatus                   bool
+	// AirflowRate is 1-8, or 0 for auto
+	AirflowRate        int
+	OperationMode      daikin.OperationMode
+	TemperatureSetting int
+	HumiditySetting    int
+	RoomHumidity       int
+	RoomTemperature    int
+	OutdoorTemperature int
+	// Unsupported is a list of EPCs the unit answers with Get_SNA.
+	Unsupported []byte
+}
+
+func DefaultState() State {
+	return State{
+		OperationStatus:               true,
+		IdentificationNumber:          [16]byte{0x00, 0x00, 0x08},
+		InstantaneousPowerConsumption: 400,
+		CumulativePowerConsumption:    123456,
+		FaultStatus:                   false,
+		AirflowRate:                   0,
+		OperationMode:                 daikin.OperationModeCooling,
+		TemperatureSetting:            26,
+		HumiditySetting:               50,
+		RoomHumidity:                  55,
+		RoomTemperature:               28,
+		OutdoorTemperature:            33,
+		Unsupported:                   []byte{},
+	}
+}
+
+// Properties encodes the state into EDTs.
+func (s State) Properties() map[byte][]byte {
+	props := map[byte][]byte{
+		daikin.EpcOperationStatus:               {onOff(s.OperationStatus)},
+		daikin.EpcIdentificationNumber:          append([]byte{0xfe}, s.IdentificationNumber[:]...),
+		daikin.EpcInstantaneousPowerConsumption: binary.BigEndian.AppendUint16(nil, s.InstantaneousPowerConsumption),
+		daikin.EpcCumulativePowerConsumption:    binary.BigEndian.AppendUint32(nil, s.CumulativePowerConsumption),
+		daikin.EpcFaultStatus:                   {0x42},
+		daikin.EpcAirflowRate:                   {0x41},
+		daikin.EpcOperationMode:                 {byte(s.OperationMode)},
+		daikin.EpcTemperatureSetting:            {byte(s.TemperatureSetting)},
+		daikin.EpcHumiditySetting:               {byte(s.HumiditySetting)},
+		daikin.EpcRoomHumidity:                  {byte(s.RoomHumidity)},
+		daikin.EpcRoomTemperature:               {byte(int8(s.RoomTemperature))},
+		daikin.EpcOutdoorTemperature:            {byte(int8(s.OutdoorTemperature))},
+		// installation location is not specified
+		echonetlite.EpcInstallationLocation: {0x00},
+		// appendix release R
+		echonetlite.EpcVersionInformation: {0x00, 0x00, 'R', 0x01},
+		echonetlite.EpcManufacturerCode:   s.IdentificationNumber[0:3],
+	}
+	if s.FaultStatus {
+		props[daikin.EpcFaultStatus] = []byte{0x41}
+	}
+	if s.AirflowRate > 0 {
+		props[daikin.EpcAirflowRate] = []byte{0x30 + byte(s.AirflowRate)}
+	}
+	for _, epc := range s.Unsupported {
+		delete(props, epc)
+	}
+	return props
+}
+
+func onOff(on bool) byte {
+	if on {
+		return 0x30
+	}
+	return 0x31
+}
+
+var (
+	settableEpcs = []byte{
+		daikin.EpcOperationStatus,
+		daikin.EpcAirflowRate,
+		daikin.EpcOperationMode,
+		daikin.EpcTemperatureSetting,
+		daikin.EpcHumiditySetting,
+	}
+	announcedEpcs = []byte{
+		daikin.EpcOperationStatus,
+		echonetlite.EpcInstallationLocation,
+		daikin.EpcFaultStatus,
+		daikin.EpcOperationMode,
+	}
+)
+
+// validate accepts the values a real unit accepts for settable EPCs.
+func validate(epc byte, edt []byte) bool {
+	if len(edt) != 1 {
+		return false
+	}
+	v := edt[0]
+	switch epc {
+	case daikin.EpcOperationStatus:
+		return v == 0x30 || v == 0x31
+	case daikin.EpcAirflowRate:
+		return v == 0x41 || (v >= 0x31 && v <= 0x38)
+	case daikin.EpcOperationMode:
+		return v >= byte(daikin.OperationModeAuto) && v <= byte(daikin.OperationModeVentilating)
+	case daikin.EpcTemperatureSetting:
+		return v <= 50
+	case daikin.EpcHumiditySetting:
+		return v <= 100
+	default:
+		return false
+	}
+}
+
+// Unit is an emulated air conditioner node hosting one or more home air
+// conditioner objects.
+type Unit struct {
+	controller echonetlite.Controller
+	eojs       []echonetlite.EOJ
+}
+
+// NewUnit creates a unit with a home air conditioner object for each state.
+// Instance codes are assigned from 1.
+func NewUnit(states ...State) *Unit {
+	u := &Unit{
+		controller: echonetlite.NewController(),
+		eojs:       []echonetlite.EOJ{},
+	}
+
+	config := echonetlite.DefaultNodeConfig
+	if len(states) > 0 {
+		copy(config.ManufacturerCode[:], states[0].IdentificationNumber[0:3])
+		copy(config.UniqueID[:], states[0].IdentificationNumber[3:])
+	}
+	u.controller.Node = echonetlite.NewNode(config)
+	for i, state := range states {
+		eoj := daikin.ObjectAircon.WithInstance(byte(i + 1))
+		u.eojs = append(u.eojs, eoj)
+
+		setEpcs := slices.DeleteFunc(slices.Clone(settableEpcs), func(epc byte) bool {
+			return slices.Contains(state.Unsupported, epc)
+		})
+		u.controller.Node.AddObject(&echonetlite.LocalObject{
+			Eoj:          eoj,
+			Properties:   state.Properties(),
+			SetEpcs:      setEpcs,
+			AnnounceEpcs: announcedEpcs,
+			Validate:     validate,
+		})
+	}
+	return u
+}
+
+func (u *Unit) SetLogger(logger *slog.Logger) {
+	u.controller.Logger = logger
+}
+
+// Start starts answering requests on transport. A UDP transport listening on
+// the default port is used if transport is nil.
+func (u *Unit) Start(transport echonetlite.Transport) error {
+	u.controller.Transport = transport
+	return u.controller.Start()
+}
+
+// ListenAndStart starts answering requests on a UDP transport on the port of
+// addr. The IP address of addr only selects the network interface to join the
+// multicast group on, and the socket is bound to the wildcard address. See
+// echonetlite.UDPTransportConfig. A unit on a port other than
+// echonetlite.DefaultPort only receives unicast requests to that port.
+func (u *Unit) ListenAndStart(network string, addr *net.UDPAddr) error {
+	u.controller.Network = network
+	u.controller.LocalAddress = addr
+	return u.controller.Start()
+}
+
+func (u *Unit) Stop() error {
+	return u.controller.Stop()
+}
+
+// Objects returns EOJs of the home air conditioner objects in the unit.
+func (u *Unit) Objects() []echonetlite.EOJ {
+	return slices.Clone(u.eojs)
+}
+
+// Property returns the current EDT of epc of the object.
+func (u *Unit) Property(eoj echonetlite.EOJ, epc byte) ([]byte, bool) {
+	return u.controller.Node.Property(eoj, epc)
+}
+
+// SetProperty overwrites the EDT of epc of the object, e.g. to simulate
+// changes of sensor values.
+func (u *Unit) SetProperty(eoj echonetlite.EOJ, epc byte, edt []byte) error {
+	return u.controller.Node.SetProperty(eoj, epc, edt)
+}
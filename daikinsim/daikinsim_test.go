@@ -0,0 +1,89 @@
+package daikinsim_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/daikin"
+	"github.com/int2xx9/daikin-airconditioner/daikinsim"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/echonettest"
+)
+
+var (
+	controllerAddr = net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 3610}
+	unitAddr       = net.UDPAddr{IP: net.IPv4(192, 0, 2, 11), Port: 3610}
+)
+
+func TestUnit(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+
+	state := daikinsim.DefaultState()
+	state.Unsupported = []byte{daikin.EpcHumiditySetting}
+	unit := daikinsim.NewUnit(state)
+	if err := unit.Start(network.Listen(unitAddr)); err != nil {
+		t.Fatalf("Start failure: %v", err)
+	}
+	defer unit.Stop()
+
+	c := echonettest.StartController(t, network, controllerAddr)
+
+	t.Run("Get", func(t *testing.T) {
+		d := daikin.NewDaikin(c)
+		resps, err := d.Request().TemperatureSetting().RoomTemperature().SetAddress(unitAddr).Query()
+		if err != nil || len(resps) != 1 {
+			t.Fatalf("Query failure: %v, %v", resps, err)
+		}
+		if v, err := resps[0].TemperatureSetting(); err != nil || v != state.TemperatureSetting {
+			t.Errorf("TemperatureSetting failure: %v, %v", v, err)
+		}
+		if v, err := resps[0].RoomTemperature(); err != nil || v != state.RoomTemperature {
+			t.Errorf("RoomTemperature failure: %v, %v", v, err)
+		}
+	})
+
+	t.Run("GetSna", func(t *testing.T) {
+		f := c.CreateFrame()
+		f.Edata = echonetlite.SpecifiedMessage{
+			Seoj:       echonetlite.ObjectController,
+			Deoj:       daikin.ObjectAircon,
+			Esv:        echonetlite.ServiceTypeGet,
+			Properties: []echonetlite.Property{{Epc: daikin.EpcHumiditySetting, Edt: []byte{}}},
+		}
+		resps, err := c.QueryBuilder().SetTimeout(5 * time.Second).SetAddress(unitAddr).Query(f)
+		if err != nil || len(resps) != 1 || resps[0].Frame.Edata.Esv != echonetlite.ServiceTypeGetSna {
+			t.Errorf("Query failure: %+v, %v", resps, err)
+		}
+	})
+
+	t.Run("SetC", func(t *testing.T) {
+		f := c.CreateFrame()
+		f.Edata = echonetlite.SpecifiedMessage{
+			Seoj: echonetlite.ObjectController,
+			Deoj: daikin.ObjectAircon,
+			Esv:  echonetlite.ServiceTypeSetC,
+			Properties: []echonetlite.Property{
+				{Epc: daikin.EpcTemperatureSetting, Edt: []byte{22}},
+				{Epc: daikin.EpcOperationMode, Edt: []byte{0x99}},
+				{Epc: daikin.EpcRoomTemperature, Edt: []byte{20}},
+			},
+		}
+		res, err := c.QueryBuilder().SetTimeout(5 * time.Second).SetAddress(unitAddr).Set(f)
+		if err != nil {
+			t.Fatalf("Set failure: %v", err)
+		}
+		if !reflect.DeepEqual(res.Accepted, []byte{daikin.EpcTemperatureSetting}) ||
+			!reflect.DeepEqual(res.Rejected, []byte{daikin.EpcOperationMode, daikin.EpcRoomTemperature}) {
+			t.Errorf("Set failure: %+v", res)
+		}
+
+		if edt, _ := unit.Property(daikin.ObjectAircon, daikin.EpcTemperatureSetting); !reflect.DeepEqual(edt, []byte{22}) {
+			t.Errorf("Property failure: %v", edt)
+		}
+		if edt, _ := unit.Property(daikin.ObjectAircon, daikin.EpcOperationMode); !reflect.DeepEqual(edt, []byte{byte(state.OperationMode)}) {
+			t.Errorf("Property failure: %v", edt)
+		}
+	})
+}
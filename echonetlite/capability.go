@@ -0,0 +1,229 @@
+package echonetlite
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrNoPropertyMap = errors.New("no property map is available")
+)
+
+// Capabilities is a set of properties which a device object supports,
+// decoded from its property maps.
+type Capabilities struct {
+	// Announce is the status change announcement property map (0x9d).
+	Announce []byte
+	// Set is the Set property map (0x9e).
+	Set []byte
+	// Get is the Get property map (0x9f).
+	Get []byte
+}
+
+func (c Capabilities) CanAnnounce(epc byte) bool {
+	return slices.Contains(c.Announce, epc)
+}
+
+func (c Capabilities) CanSet(epc byte) bool {
+	return slices.Contains(c.Set, epc)
+}
+
+func (c Capabilities) CanGet(epc byte) bool {
+	return slices.Contains(c.Get, epc)
+}
+
+// FilterGet returns epcs which are in the Get property map.
+func (c Capabilities) FilterGet(epcs []byte) []byte {
+	supported := []byte{}
+	for _, epc := range epcs {
+		if c.CanGet(epc) {
+			supported = append(supported, epc)
+		}
+	}
+	return supported
+}
+
+type capabilityKey struct {
+	host string
+	eoj  EOJ
+}
+
+// newCapabilityKey ignores the port and the zone of addr, so a target
+// configured without a zone shares the entry with responses from it.
+func newCapabilityKey(addr net.UDPAddr, eoj EOJ) capabilityKey {
+	return capabilityKey{host: addr.IP.String(), eoj: eoj}
+}
+
+const (
+	// capabilityRetryInterval is how long it is cached that a device object
+	// has no valid property maps, or a device has no instances of a class,
+	// so a device which doesn't support them costs no round trips on every
+	// query.
+	capabilityRetryInterval = 5 * time.Minute
+	// noResponseRetryInterval is how long it is cached that a device didn't
+	// respond to a query of its property maps. The response may have been
+	// lost, so it is retried much sooner.
+	noResponseRetryInterval = 10 * time.Second
+)
+
+type capabilityEntry struct {
+	caps Capabilities
+	err  error
+	// expires is zero for capabilities, which are cached until forgotten.
+	expires time.Time
+}
+
+type instanceEntry struct {
+	count int
+	// expires is zero if count is known.
+	expires time.Time
+}
+
+// capabilityCache caches the property maps of device objects, and the number
+// of instances of classes in devices keyed by the EOJ with the instance code
+// 0.
+type capabilityCache struct {
+	m         sync.Mutex
+	entries   map[capabilityKey]capabilityEntry
+	instances map[capabilityKey]instanceEntry
+}
+
+func (c *capabilityCache) get(key capabilityKey) (capabilityEntry, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || (!entry.expires.IsZero() && time.Now().After(entry.expires)) {
+		return capabilityEntry{}, false
+	}
+	return entry, true
+}
+
+func (c *capabilityCache) set(key capabilityKey, caps Capabilities) {
+	c.setEntry(key, capabilityEntry{caps: caps})
+}
+
+func (c *capabilityCache) setErr(key capabilityKey, err error, interval time.Duration) {
+	c.setEntry(key, capabilityEntry{err: err, expires: time.Now().Add(interval)})
+}
+
+func (c *capabilityCache) setEntry(key capabilityKey, entry capabilityEntry) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.entries == nil {
+		c.entries = map[capabilityKey]capabilityEntry{}
+	}
+	c.entries[key] = entry
+}
+
+func (c *capabilityCache) getInstances(key capabilityKey) (int, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	entry, ok := c.instances[key]
+	if !ok || (!entry.expires.IsZero() && time.Now().After(entry.expires)) {
+		return 0, false
+	}
+	return entry.count, true
+}
+
+// setInstances caches count of a device which responded to Discover, or that
+// it has no instances for capabilityRetryInterval if count is 0.
+func (c *capabilityCache) setInstances(key capabilityKey, count int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.instances == nil {
+		c.instances = map[capabilityKey]instanceEntry{}
+	}
+	entry := instanceEntry{count: count}
+	if count == 0 {
+		entry.expires = time.Now().Add(capabilityRetryInterval)
+	}
+	c.instances[key] = entry
+}
+
+func (c *capabilityCache) remove(key capabilityKey) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	delete(c.entries, key)
+	delete(c.instances, capabilityKey{host: key.host, eoj: key.eoj.WithInstance(0)})
+}
+
+// ForgetCapabilities removes the cached capabilities of eoj at addr and the
+// cached number of instances of its class, e.g. after the firmware of the
+// device is updated.
+func (c *Controller) ForgetCapabilities(addr net.UDPAddr, eoj EOJ) {
+	c.capabilities.remove(newCapabilityKey(addr, eoj))
+}
+
+// Capabilities returns the property maps of eoj at the address specified by
+// SetAddress. The property maps are queried once per device object and
+// cached by the controller. A response without valid property maps is cached
+// for a while too, and no response only briefly. Nothing is cached if ctx is
+// done or the request could not be sent.
+func (q QueryBuilder) Capabilities(ctx context.Context, eoj EOJ) (Capabilities, error) {
+	if q.Address == nil {
+		return Capabilities{}, ErrNoAddress
+	}
+
+	key := newCapabilityKey(*q.Address, eoj)
+	if entry, ok := q.controller.capabilities.get(key); ok {
+		return entry.caps, entry.err
+	}
+
+	f := q.controller.CreateFrame()
+	f.Edata = SpecifiedMessage{
+		Seoj: ObjectController,
+		Deoj: eoj,
+		Esv:  ServiceTypeGet,
+		Properties: []Property{
+			{Epc: EpcAnnouncePropertyMap, Edt: []byte{}},
+			{Epc: EpcSetPropertyMap, Edt: []byte{}},
+			{Epc: EpcGetPropertyMap, Edt: []byte{}},
+		},
+	}
+
+	responses, err := q.QueryContext(ctx, f, QueryOptions{})
+	if err != nil {
+		return Capabilities{}, err
+	}
+	if len(responses) < 1 {
+		q.controller.capabilities.setErr(key, ErrNoResponse, noResponseRetryInterval)
+		return Capabilities{}, ErrNoResponse
+	}
+
+	caps := Capabilities{Announce: []byte{}, Set: []byte{}, Get: []byte{}}
+	hasGetMap := false
+	for _, prop := range responses[0].Frame.Edata.Properties {
+		if len(prop.Edt) == 0 {
+			// not available in Get_SNA
+			continue
+		}
+		epcs, err := GetPropertyMap(prop)
+		if err != nil {
+			q.controller.capabilities.setErr(key, err, capabilityRetryInterval)
+			return Capabilities{}, err
+		}
+		switch prop.Epc {
+		case EpcAnnouncePropertyMap:
+			caps.Announce = epcs
+		case EpcSetPropertyMap:
+			caps.Set = epcs
+		case EpcGetPropertyMap:
+			caps.Get = epcs
+			hasGetMap = true
+		}
+	}
+	// The Get property map is mandatory, so a response without it is not
+	// worth filtering EPCs with.
+	if !hasGetMap {
+		q.controller.capabilities.setErr(key, ErrNoPropertyMap, capabilityRetryInterval)
+		return Capabilities{}, ErrNoPropertyMap
+	}
+
+	q.controller.capabilities.set(key, caps)
+	return caps, nil
+}
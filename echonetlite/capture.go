@@ -0,0 +1,229 @@
+package echonetlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+)
+
+var (
+	ErrUnsupportedCapture = errors.New("unsupported capture format")
+	ErrNotUDPPacket       = errors.New("not a udp packet")
+)
+
+// Direction is whether a captured packet was received or sent.
+type Direction int
+
+const (
+	DirectionUnknown Direction = iota
+	DirectionInbound
+	DirectionOutbound
+)
+
+// CapturedPacket is an ECHONET Lite datagram in a capture file.
+type CapturedPacket struct {
+	Time      time.Time
+	Direction Direction
+	Src       net.UDPAddr
+	Dst       net.UDPAddr
+	// Data is the UDP payload.
+	Data []byte
+}
+
+// PacketWriter writes packets to a capture file. See NewPcapWriter and
+// NewPcapngWriter.
+type PacketWriter interface {
+	WritePacket(p CapturedPacket) error
+}
+
+// encodeIPPacket builds an IPv4 or IPv6 packet carrying p as a UDP datagram,
+// so that captures can be opened in packet analyzers.
+func encodeIPPacket(p CapturedPacket) []byte {
+	udp := make([]byte, 8+len(p.Data))
+	binary.BigEndian.PutUint16(udp[0:], uint16(p.Src.Port))
+	binary.BigEndian.PutUint16(udp[2:], uint16(p.Dst.Port))
+	binary.BigEndian.PutUint16(udp[4:], uint16(len(udp)))
+	copy(udp[8:], p.Data)
+
+	src4, dst4 := p.Src.IP.To4(), p.Dst.IP.To4()
+	if src4 != nil && dst4 != nil {
+		pseudo := append(append([]byte{}, src4...), dst4...)
+		pseudo = append(pseudo, 0, 17, byte(len(udp)>>8), byte(len(udp)))
+		binary.BigEndian.PutUint16(udp[6:], udpChecksum(pseudo, udp))
+
+		ip := make([]byte, 20, 20+len(udp))
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:], uint16(20+len(udp)))
+		ip[8] = 64
+		ip[9] = 17
+		copy(ip[12:], src4)
+		copy(ip[16:], dst4)
+		binary.BigEndian.PutUint16(ip[10:], ^checksum(0, ip))
+		return append(ip, udp...)
+	}
+
+	src16, dst16 := p.Src.IP.To16(), p.Dst.IP.To16()
+	if src16 == nil {
+		src16 = net.IPv6unspecified
+	}
+	if dst16 == nil {
+		dst16 = net.IPv6unspecified
+	}
+	pseudo := append(append([]byte{}, src16...), dst16...)
+	pseudo = append(pseudo, 0, 0, byte(len(udp)>>8), byte(len(udp)), 0, 0, 0, 17)
+	binary.BigEndian.PutUint16(udp[6:], udpChecksum(pseudo, udp))
+
+	ip := make([]byte, 40, 40+len(udp))
+	ip[0] = 0x60
+	binary.BigEndian.PutUint16(ip[4:], uint16(len(udp)))
+	ip[6] = 17
+	ip[7] = 64
+	copy(ip[8:], src16)
+	copy(ip[24:], dst16)
+	return append(ip, udp...)
+}
+
+func checksum(sum uint32, data []byte) uint16 {
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return uint16(sum)
+}
+
+func udpChecksum(pseudo []byte, udp []byte) uint16 {
+	sum := ^checksum(uint32(checksum(0, pseudo)), udp)
+	if sum == 0 {
+		return 0xffff
+	}
+	return sum
+}
+
+// decodeIPPacket extracts a UDP datagram from an IPv4 or IPv6 packet.
+func decodeIPPacket(data []byte) (CapturedPacket, error) {
+	if len(data) < 1 {
+		return CapturedPacket{}, ErrNotUDPPacket
+	}
+
+	var udp []byte
+	p := CapturedPacket{}
+	switch data[0] >> 4 {
+	case 4:
+		ihl := int(data[0]&0x0f) * 4
+		if ihl < 20 || len(data) < ihl {
+			return CapturedPacket{}, ErrNotUDPPacket
+		}
+		total := int(binary.BigEndian.Uint16(data[2:]))
+		fragmented := binary.BigEndian.Uint16(data[6:])&0x3fff != 0
+		if data[9] != 17 || fragmented || total < ihl || total > len(data) {
+			return CapturedPacket{}, ErrNotUDPPacket
+		}
+		p.Src.IP = net.IP(append([]byte{}, data[12:16]...))
+		p.Dst.IP = net.IP(append([]byte{}, data[16:20]...))
+		udp = data[ihl:total]
+	case 6:
+		if len(data) < 40 || data[6] != 17 {
+			return CapturedPacket{}, ErrNotUDPPacket
+		}
+		length := int(binary.BigEndian.Uint16(data[4:]))
+		if 40+length > len(data) {
+			return CapturedPacket{}, ErrNotUDPPacket
+		}
+		p.Src.IP = net.IP(append([]byte{}, data[8:24]...))
+		p.Dst.IP = net.IP(append([]byte{}, data[24:40]...))
+		udp = data[40 : 40+length]
+	default:
+		return CapturedPacket{}, ErrNotUDPPacket
+	}
+
+	if len(udp) < 8 {
+		return CapturedPacket{}, ErrNotUDPPacket
+	}
+	length := int(binary.BigEndian.Uint16(udp[4:]))
+	if length < 8 || length > len(udp) {
+		return CapturedPacket{}, ErrNotUDPPacket
+	}
+	p.Src.Port = int(binary.BigEndian.Uint16(udp[0:]))
+	p.Dst.Port = int(binary.BigEndian.Uint16(udp[2:]))
+	p.Data = append([]byte{}, udp[8:length]...)
+	return p, nil
+}
+
+// decodeEthernetFrame extracts a UDP datagram from an Ethernet frame.
+func decodeEthernetFrame(data []byte) (CapturedPacket, error) {
+	offset := 12
+	for {
+		if len(data) < offset+2 {
+			return CapturedPacket{}, ErrNotUDPPacket
+		}
+		switch binary.BigEndian.Uint16(data[offset:]) {
+		case 0x8100, 0x88a8:
+			// VLAN tags
+			offset += 4
+		case 0x0800, 0x86dd:
+			return decodeIPPacket(data[offset+2:])
+		default:
+			return CapturedPacket{}, ErrNotUDPPacket
+		}
+	}
+}
+
+// capture records datagrams sent and received by the controller.
+func (c *Controller) capture(direction Direction, peer net.UDPAddr, data []byte) {
+	if c.Capture == nil {
+		return
+	}
+
+	local := c.captureLocalAddress(peer)
+	p := CapturedPacket{Time: time.Now(), Direction: direction, Src: peer, Dst: local, Data: data}
+	if direction == DirectionOutbound {
+		p.Src, p.Dst = local, peer
+	}
+	if err := c.Capture.WritePacket(p); err != nil {
+		c.Logger.Debug("[capture] error", "err", err)
+	}
+}
+
+// captureLocalAddress guesses the local address which communicates with
+// peer, since transports don't tell it.
+func (c *Controller) captureLocalAddress(peer net.UDPAddr) net.UDPAddr {
+	local := net.UDPAddr{Port: DefaultPort}
+	if c.LocalAddress != nil {
+		local = *c.LocalAddress
+	} else if t, ok := c.Transport.(interface{ Addr() net.UDPAddr }); ok {
+		local = t.Addr()
+	}
+	if local.Port == 0 {
+		local.Port = DefaultPort
+	}
+
+	if (local.IP.To4() != nil) != (peer.IP.To4() != nil) || local.IP == nil {
+		if peer.IP.To4() != nil {
+			local.IP = net.IPv4zero
+		} else {
+			local.IP = net.IPv6unspecified
+		}
+	}
+	return local
+}
+
+// multicastGroups returns the multicast groups the controller sends to.
+func (c *Controller) multicastGroups() []net.UDPAddr {
+	groups := []net.UDPAddr{}
+	networks, err := udpNetworks(c.Network)
+	if err != nil {
+		return groups
+	}
+	for _, network := range networks {
+		if addr, err := multicastAddress(network, c.Interface); err == nil {
+			groups = append(groups, *addr)
+		}
+	}
+	return groups
+}
@@ -0,0 +1,153 @@
+package echonetlite_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+func TestCapture(t *testing.T) {
+	now := time.Unix(1700000000, 123456000)
+	packets := []echonetlite.CapturedPacket{
+		{
+			Time:      now,
+			Direction: echonetlite.DirectionOutbound,
+			Src:       net.UDPAddr{IP: net.IPv4(192, 0, 2, 1).To4(), Port: 3610},
+			Dst:       net.UDPAddr{IP: net.IPv4(224, 0, 23, 0).To4(), Port: 3610},
+			Data:      []byte{0x10, 0x81, 0x00, 0x01, 0x05, 0xff, 0x01, 0x01, 0x30, 0x01, 0x62, 0x01, 0x80, 0x00},
+		},
+		{
+			Time:      now.Add(time.Millisecond),
+			Direction: echonetlite.DirectionInbound,
+			Src:       net.UDPAddr{IP: net.ParseIP("fe80::11"), Port: 3610},
+			Dst:       net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 3610},
+			Data:      []byte{0x10, 0x81, 0x00, 0x01, 0x01, 0x30, 0x01, 0x05, 0xff, 0x01, 0x72, 0x01, 0x80, 0x01, 0x30},
+		},
+	}
+
+	for name, newWriter := range map[string]func(buf *bytes.Buffer) (echonetlite.PacketWriter, error){
+		"pcap":   func(buf *bytes.Buffer) (echonetlite.PacketWriter, error) { return echonetlite.NewPcapWriter(buf) },
+		"pcapng": func(buf *bytes.Buffer) (echonetlite.PacketWriter, error) { return echonetlite.NewPcapngWriter(buf) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w, err := newWriter(buf)
+			if err != nil {
+				t.Fatalf("writer failure: %v", err)
+			}
+			for _, p := range packets {
+				if err := w.WritePacket(p); err != nil {
+					t.Fatalf("WritePacket failure: %v", err)
+				}
+			}
+
+			read, err := echonetlite.ReadCapture(buf)
+			if err != nil {
+				t.Fatalf("ReadCapture failure: %v", err)
+			}
+			if len(read) != len(packets) {
+				t.Fatalf("ReadCapture failure: %d packets", len(read))
+			}
+			for i, p := range read {
+				expect := packets[i]
+				if name == "pcap" {
+					expect.Direction = echonetlite.DirectionUnknown
+				}
+				if !p.Time.Equal(expect.Time) || p.Direction != expect.Direction || !reflect.DeepEqual(p.Data, expect.Data) ||
+					!p.Src.IP.Equal(expect.Src.IP) || p.Src.Port != expect.Src.Port ||
+					!p.Dst.IP.Equal(expect.Dst.IP) || p.Dst.Port != expect.Dst.Port {
+					t.Errorf("ReadCapture failure: %+v, expected %+v", p, expect)
+				}
+			}
+		})
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		if _, err := echonetlite.ReadCapture(bytes.NewReader([]byte("not a capture file"))); err != echonetlite.ErrUnsupportedCapture {
+			t.Errorf("ReadCapture failure: %v", err)
+		}
+	})
+
+	t.Run("WrongLength", func(t *testing.T) {
+		pcap := &bytes.Buffer{}
+		if _, err := echonetlite.NewPcapWriter(pcap); err != nil {
+			t.Fatalf("NewPcapWriter failure: %v", err)
+		}
+		// a record header with a captured length of 4 GiB
+		pcap.Write([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+
+		pcapng := &bytes.Buffer{}
+		if _, err := echonetlite.NewPcapngWriter(pcapng); err != nil {
+			t.Fatalf("NewPcapngWriter failure: %v", err)
+		}
+		// enhanced packet blocks too short and too long
+		short := append(append([]byte{}, pcapng.Bytes()...), 0x06, 0, 0, 0, 0x04, 0, 0, 0)
+		pcapng.Write([]byte{0x06, 0, 0, 0, 0xfc, 0xff, 0xff, 0xff})
+
+		for name, data := range map[string][]byte{"pcap": pcap.Bytes(), "pcapng": pcapng.Bytes(), "short": short} {
+			var decodeError *echonetlite.DecodeError
+			if _, err := echonetlite.ReadCapture(bytes.NewReader(data)); !errors.As(err, &decodeError) || !errors.Is(err, echonetlite.ErrWrongLength) {
+				t.Errorf("ReadCapture failure for %s: %v", name, err)
+			}
+		}
+	})
+}
+
+func TestReplay(t *testing.T) {
+	query := func(c *echonetlite.Controller) []echonetlite.QueryResponse {
+		responses, err := c.QueryBuilder().SetTimeout(time.Second).SetAddress(device1Addr).QueryContext(context.Background(), getFrame(c, 0x013001, 0x80), echonetlite.QueryOptions{})
+		if err != nil || len(responses) != 1 {
+			t.Fatalf("Query failure: %+v, %v", responses, err)
+		}
+		return responses
+	}
+
+	// record a query to a device
+	buf := &bytes.Buffer{}
+	w, err := echonetlite.NewPcapngWriter(buf)
+	if err != nil {
+		t.Fatalf("NewPcapngWriter failure: %v", err)
+	}
+	network := echonetlite.NewMemoryNetwork()
+	startDevice(t, network, device1Addr, 0x30)
+	recorder := echonetlite.NewController()
+	recorder.Transport = network.Listen(controllerAddr)
+	recorder.Capture = w
+	if err := recorder.Start(); err != nil {
+		t.Fatalf("Start failure: %v", err)
+	}
+	recorded := query(&recorder)
+	recorder.Stop()
+
+	packets, err := echonetlite.ReadCapture(buf)
+	if err != nil || len(packets) != 2 {
+		t.Fatalf("ReadCapture failure: %+v, %v", packets, err)
+	}
+	if packets[0].Direction != echonetlite.DirectionOutbound || !packets[0].Src.IP.Equal(controllerAddr.IP) || !packets[0].Dst.IP.Equal(device1Addr.IP) {
+		t.Errorf("Capture failure: %+v", packets[0])
+	}
+
+	// replay the response without the device
+	transport := echonetlite.NewReplayTransport(packets)
+	replayer := echonetlite.NewController()
+	replayer.Transport = transport
+	if err := replayer.Start(); err != nil {
+		t.Fatalf("Start failure: %v", err)
+	}
+	defer replayer.Stop()
+	// the TID of the response is rewritten to the TID of the request
+	replayer.CreateFrame()
+	replayed := query(&replayer)
+	if replayed[0].Frame.Tid == recorded[0].Frame.Tid || !reflect.DeepEqual(replayed[0].Frame.Edata, recorded[0].Frame.Edata) {
+		t.Errorf("Replay failure: %+v, expected %+v", replayed[0].Frame, recorded[0].Frame)
+	}
+	if sent := transport.Sent(); len(sent) != 1 || !bytes.Equal(sent[0][4:], packets[0].Data[4:]) {
+		t.Errorf("Sent failure: %v", sent)
+	}
+}
@@ -0,0 +1,119 @@
+package echonetlite
+
+import (
+	"context"
+	"net"
+)
+
+// NodeInfo is an inventory of a node found by Discover.
+type NodeInfo struct {
+	Addr net.UDPAddr
+	// InstanceCount is the number of device object instances in the node.
+	// It may exceed len(Instances) since the instance list holds up to 84
+	// instances.
+	InstanceCount int
+	// Instances is a list of EOJs of device objects in the node.
+	Instances []EOJ
+	// Classes is a list of class group codes and class codes of device
+	// objects in the node, e.g. 0x0130 for home air conditioners.
+	Classes []uint16
+}
+
+// InstancesOf returns EOJs of the instances of class in the node.
+func (n NodeInfo) InstancesOf(class uint16) []EOJ {
+	eojs := []EOJ{}
+	for _, eoj := range n.Instances {
+		if eoj.Class() == class {
+			eojs = append(eojs, eoj)
+		}
+	}
+	return eojs
+}
+
+// Discover queries the number of instances (0xd3), the self-node instance
+// list S (0xd6) and the self-node class list S (0xd7) of node profile objects
+// and returns an inventory of nodes which responded.
+func (q QueryBuilder) Discover(ctx context.Context) ([]NodeInfo, error) {
+	f := q.controller.CreateFrame()
+	f.Edata = SpecifiedMessage{
+		Seoj: ObjectController,
+		Deoj: ObjectNodeProfile,
+		Esv:  ServiceTypeGet,
+		Properties: []Property{
+			{Epc: EpcNumberOfSelfNodeInstances, Edt: []byte{}},
+			{Epc: EpcSelfNodeInstanceListS, Edt: []byte{}},
+			{Epc: EpcSelfNodeClassListS, Edt: []byte{}},
+		},
+	}
+
+	responses, err := q.QueryContext(ctx, f, QueryOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []NodeInfo{}
+	for _, res := range responses {
+		if containsNode(nodes, res.Addr) {
+			continue
+		}
+
+		node := NodeInfo{
+			Addr:      res.Addr,
+			Instances: []EOJ{},
+			Classes:   []uint16{},
+		}
+		for _, prop := range res.Frame.Edata.Properties {
+			switch prop.Epc {
+			case EpcNumberOfSelfNodeInstances:
+				if len(prop.Edt) == 3 {
+					node.InstanceCount = int(prop.Edt[0])<<16 | int(prop.Edt[1])<<8 | int(prop.Edt[2])
+				}
+			case EpcSelfNodeInstanceListS:
+				node.Instances = parseInstanceList(prop.Edt)
+			case EpcSelfNodeClassListS:
+				node.Classes = parseClassList(prop.Edt)
+			}
+		}
+		if node.InstanceCount < len(node.Instances) {
+			node.InstanceCount = len(node.Instances)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func containsNode(nodes []NodeInfo, addr net.UDPAddr) bool {
+	for _, node := range nodes {
+		if sameHost(node.Addr, addr) && node.Addr.Port == addr.Port {
+			return true
+		}
+	}
+	return false
+}
+
+// parseInstanceList parses an instance list (0xd5, 0xd6). Instances beyond
+// the end of edt are ignored.
+func parseInstanceList(edt []byte) []EOJ {
+	eojs := []EOJ{}
+	if len(edt) < 1 {
+		return eojs
+	}
+	for i := 0; i < int(edt[0]) && 1+3*i+3 <= len(edt); i++ {
+		eojs = append(eojs, decodeEOJ(edt[1+3*i:]))
+	}
+	return eojs
+}
+
+// parseClassList parses a class list (0xd7). Classes beyond the end of edt
+// are ignored.
+func parseClassList(edt []byte) []uint16 {
+	classes := []uint16{}
+	if len(edt) < 1 {
+		return classes
+	}
+	for i := 0; i < int(edt[0]) && 1+2*i+2 <= len(edt); i++ {
+		b := edt[1+2*i:]
+		classes = append(classes, uint16(b[0])<<8|uint16(b[1]))
+	}
+	return classes
+}
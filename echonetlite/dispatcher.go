@@ -0,0 +1,111 @@
+package echonetlite
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// dispatcher routes received frames to pending requests by TID, so a frame
+// is examined only by the receivers which wait for its TID.
+type dispatcher struct {
+	m sync.RWMutex
+	// pending holds receivers for each TID. More than one receiver may wait
+	// for a TID since TIDs wrap around and frames may be built by the caller.
+	pending map[uint16][]*receiver
+}
+
+func (d *dispatcher) Add(r *receiver) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	if d.pending == nil {
+		d.pending = map[uint16][]*receiver{}
+	}
+	d.pending[r.tid] = append(d.pending[r.tid], r)
+}
+
+func (d *dispatcher) Remove(r *receiver) {
+	d.m.Lock()
+	defer d.m.Unlock()
+
+	receivers := slices.DeleteFunc(slices.Clone(d.pending[r.tid]), func(p *receiver) bool { return p == r })
+	if len(receivers) == 0 {
+		delete(d.pending, r.tid)
+	} else {
+		d.pending[r.tid] = receivers
+	}
+}
+
+func (d *dispatcher) Dispatch(addr net.UDPAddr, f Frame) {
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	for _, r := range d.pending[f.Tid] {
+		r.Accept(addr, f)
+	}
+}
+
+type receiverData struct {
+	addr net.UDPAddr
+	data Frame
+}
+
+// receiver collects responses to a request.
+type receiver struct {
+	m      sync.Mutex
+	tid    uint16
+	addr   *net.UDPAddr
+	esvs   []ServiceType
+	data   []receiverData
+	notify chan struct{}
+}
+
+func newReceiver(tid uint16, addr *net.UDPAddr, esvs ...ServiceType) *receiver {
+	return &receiver{
+		tid:    tid,
+		addr:   addr,
+		esvs:   esvs,
+		data:   []receiverData{},
+		notify: make(chan struct{}, 1),
+	}
+}
+
+func (r *receiver) Accept(addr net.UDPAddr, frame Frame) bool {
+	if frame.Tid != r.tid {
+		return false
+	}
+	if r.addr != nil && !sameHost(*r.addr, addr) {
+		return false
+	}
+	if len(r.esvs) > 0 && !slices.Contains(r.esvs, frame.Edata.Esv) {
+		return false
+	}
+
+	r.m.Lock()
+	// a device may respond to each of retransmitted frames
+	duplicated := slices.ContainsFunc(r.data, func(d receiverData) bool {
+		return sameHost(d.addr, addr) && d.addr.Port == addr.Port && d.data.Edata.Seoj == frame.Edata.Seoj
+	})
+	if !duplicated {
+		r.data = append(r.data, receiverData{addr, frame})
+	}
+	r.m.Unlock()
+	if duplicated {
+		return true
+	}
+
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (r *receiver) received() []receiverData {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	return slices.Clone(r.data)
+}
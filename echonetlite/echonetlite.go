@@ -8,6 +8,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 )
 
@@ -15,23 +16,53 @@ var (
 	ErrAlreadyStarted     = errors.New("a controller is already started")
 	ErrNotQueryMessage    = errors.New("not a query message")
 	ErrUnsupportedMessage = errors.New("unsupported message")
+	ErrNoAddress          = errors.New("no address is specified")
+	ErrNoResponse         = errors.New("no response")
+	ErrNotStarted         = errors.New("a controller is not started")
+	ErrNoSuchInterface    = errors.New("no interface has the address")
+	ErrUnsupportedNetwork = errors.New("unsupported network")
 )
 
 const (
-	BroadcastAddress = "224.0.23.0:3610"
+	BroadcastAddress     = "224.0.23.0:3610"
+	BroadcastAddressIPv6 = "[ff02::1]:3610"
+	DefaultPort          = 3610
+	// DefaultTimeout is the timeout of a query whose QueryBuilder has no
+	// timeout and whose context has no deadline.
+	DefaultTimeout = 1 * time.Second
 )
 
 type Controller struct {
-	connectionCancel func()
-	receivers        receiverCollection
-	currentTid       uint32
-	Logger           *slog.Logger
+	transportMutex sync.RWMutex
+	transport      Transport
+	dispatcher     dispatcher
+	subscriptions  subscriptionCollection
+	capabilities   capabilityCache
+	currentTid     uint32
+	Logger         *slog.Logger
+	// Node answers requests to the node profile and objects hosted by the
+	// controller. No requests are answered if it is nil.
+	Node *Node
+	// Transport is used instead of UDP sockets if it is not nil. It is
+	// closed when the controller stops.
+	Transport Transport
+	// Interface, LocalAddress and Network configure UDP sockets.
+	// See UDPTransportConfig.
+	Interface    *net.Interface
+	LocalAddress *net.UDPAddr
+	Network      string
+	// Capture records every datagram sent and received if it is not nil.
+	// It must be safe for concurrent use like PcapWriter and PcapngWriter.
+	Capture PacketWriter
+	// Trace logs every frame sent and received at the debug level with
+	// names and decoded values of properties. See Frame.String.
+	Trace bool
 }
 
 func NewController() Controller {
 	return Controller{
-		receivers: newReceiverCollection(),
-		Logger:    slog.Default(),
+		Logger: slog.Default(),
+		Node:   NewNode(DefaultNodeConfig, NewControllerObject(DefaultNodeConfig)),
 	}
 }
 
@@ -49,72 +80,99 @@ func (c *Controller) Close() error {
 }
 
 func (c *Controller) Start() error {
-	if c.connectionCancel != nil {
-		return ErrAlreadyStarted
-	}
+	c.transportMutex.Lock()
+	defer c.transportMutex.Unlock()
 
-	udpAddr, err := net.ResolveUDPAddr("udp", "0.0.0.0:3610")
-	if err != nil {
-		slog.Debug("%+v", err)
-		return err
+	if c.transport != nil {
+		return ErrAlreadyStarted
 	}
 
-	conn, err := net.ListenUDP("udp", udpAddr)
-	if err != nil {
-		slog.Debug("%+v", err)
-		return err
+	transport := c.Transport
+	if transport == nil {
+		udpTransport, err := ListenUDP(UDPTransportConfig{
+			Network:      c.Network,
+			Interface:    c.Interface,
+			LocalAddress: c.LocalAddress,
+		})
+		if err != nil {
+			c.Logger.Debug("[Start] error", "err", err)
+			return err
+		}
+		transport = udpTransport
 	}
+	c.transport = transport
 
-	ctx, cancel := context.WithCancel(context.Background())
-	c.connectionCancel = cancel
-
-	c.receivers = newReceiverCollection()
-
-	go c.udpListener(ctx, conn)
+	go c.listener(transport)
 	return nil
 }
 
-func (c *Controller) udpListener(ctx context.Context, conn *net.UDPConn) {
-	defer conn.Close()
-
-	buf := make([]byte, 1024)
+func (c *Controller) listener(transport Transport) {
 	for {
-		select {
-		case <-ctx.Done():
+		data, addr, err := transport.Receive()
+		if errors.Is(err, net.ErrClosed) {
 			return
-		default:
-			conn.SetDeadline(time.Now().Add(1 * time.Second))
-			n, addr, err := conn.ReadFromUDP(buf)
-			if err, ok := err.(net.Error); ok && err.Timeout() {
-				continue
-			} else if err != nil {
-				c.Logger.Debug("[udpListener] error", "err", err)
-				continue
-			} else if n >= 1024 {
-				c.Logger.Debug("[udpListener] large data is arrived, ignore it")
-				continue
-			}
+		} else if err != nil {
+			c.Logger.Debug("[listener] error", "err", err)
+			continue
+		}
+		c.capture(DirectionInbound, addr, data)
+		c.trace(DirectionInbound, addr, data)
 
-			data := buf[:n]
-			frame, err := DeserializeFrame(data)
-			if err != nil {
-				c.Logger.Debug("[udpListener] error", "err", err)
-				continue
+		frame, err := DeserializeFrame(data)
+		if err != nil {
+			c.Logger.Debug("[listener] error", "err", err)
+			continue
+		}
+		if frame.Edata.Esv == ServiceTypeInfC {
+			if err := c.respondInfC(addr, frame); err != nil {
+				c.Logger.Debug("[listener] failed to respond to InfC", "err", err)
+			}
+		}
+		if c.Node != nil {
+			for _, out := range c.Node.handle(addr, frame) {
+				if err := c.send(out.addr, out.frame); err != nil {
+					c.Logger.Debug("[listener] failed to respond", "err", err)
+				}
 			}
-			c.receivers.AcceptAll(*addr, frame)
 		}
+		c.dispatcher.Dispatch(addr, frame)
+		c.subscriptions.NotifyAll(addr, frame)
 	}
 }
 
+// respondInfC acknowledges an InfC frame with an InfCRes frame which has the
+// same TID, swapped EOJs and the notified EPCs without EDTs.
+func (c *Controller) respondInfC(addr net.UDPAddr, f Frame) error {
+	res := Frame{
+		Ehd1: 0x10,
+		Ehd2: 0x81,
+		Tid:  f.Tid,
+		Edata: SpecifiedMessage{
+			Seoj:       f.Edata.Deoj,
+			Deoj:       f.Edata.Seoj,
+			Esv:        ServiceTypeInfCRes,
+			Properties: []Property{},
+		},
+	}
+	for _, prop := range f.Edata.Properties {
+		res.Edata.Properties = append(res.Edata.Properties, Property{Epc: prop.Epc, Edt: []byte{}})
+	}
+
+	return c.send(&addr, res)
+}
+
 func (c *Controller) Stop() error {
-	if c.connectionCancel == nil {
+	c.transportMutex.Lock()
+	defer c.transportMutex.Unlock()
+
+	if c.transport == nil {
 		return nil
 	}
 
-	c.connectionCancel()
-	c.connectionCancel = nil
+	err := c.transport.Close()
+	c.transport = nil
 
-	return nil
+	return err
 }
 
 func (c *Controller) QueryBuilder() *QueryBuilder {
@@ -128,27 +186,61 @@ func (c *Controller) Execute(addr net.UDPAddr, f Frame) error {
 		return ErrUnsupportedMessage
 	}
 
-	conn, err := net.DialUDP("udp", nil, &addr)
-	if err != nil {
-		return err
+	return c.send(&addr, f)
+}
+
+// send sends f through the transport the controller listens on, so that it
+// goes out on the selected interface with the source port the controller
+// listens on. f is sent to the multicast group if addr is nil.
+func (c *Controller) send(addr *net.UDPAddr, f Frame) error {
+	c.transportMutex.RLock()
+	transport := c.transport
+	c.transportMutex.RUnlock()
+
+	if transport == nil {
+		return ErrNotStarted
 	}
-	defer conn.Close()
 
 	frameBytes, err := f.Serialize()
 	if err != nil {
 		return err
 	}
-	_, err = conn.Write(frameBytes)
-	if err != nil {
+
+	if addr == nil {
+		if err := transport.SendMulticast(frameBytes); err != nil {
+			return err
+		}
+		for _, group := range c.multicastGroups() {
+			c.capture(DirectionOutbound, group, frameBytes)
+			c.trace(DirectionOutbound, group, frameBytes)
+		}
+		return nil
+	}
+	if err := transport.Send(*addr, frameBytes); err != nil {
 		return err
 	}
-
+	c.capture(DirectionOutbound, *addr, frameBytes)
+	c.trace(DirectionOutbound, *addr, frameBytes)
 	return nil
 }
 
+// sameHost reports whether a and b are the same IP address. Zones are
+// compared only if both of them have one.
+func sameHost(a, b net.UDPAddr) bool {
+	if !a.IP.Equal(b.IP) {
+		return false
+	}
+	return a.Zone == "" || b.Zone == "" || a.Zone == b.Zone
+}
+
 type QueryBuilder struct {
 	controller *Controller
-	Timeout    time.Duration
+	// Timeout is how long each attempt of a query waits for responses. If
+	// it is not positive, the deadline of the context is used, or
+	// DefaultTimeout if the context has no deadline.
+	Timeout time.Duration
+	Address *net.UDPAddr
+	Retry   RetryPolicy
 }
 
 func (q *QueryBuilder) SetTimeout(duration time.Duration) *QueryBuilder {
@@ -156,115 +248,265 @@ func (q *QueryBuilder) SetTimeout(duration time.Duration) *QueryBuilder {
 	return q
 }
 
+// SetAddress makes the query unicast to addr instead of the multicast group.
+// Only responses from addr are collected.
+func (q *QueryBuilder) SetAddress(addr net.UDPAddr) *QueryBuilder {
+	q.Address = &addr
+	return q
+}
+
+// SetRetry makes queries retry lost responses according to policy.
+func (q *QueryBuilder) SetRetry(policy RetryPolicy) *QueryBuilder {
+	q.Retry = policy
+	return q
+}
+
 func (q QueryBuilder) Query(f Frame) ([]QueryResponse, error) {
-	if f.Ehd1 != 0x10 || f.Ehd2 != 0x81 || f.Edata.Esv != 0x62 {
-		return nil, ErrNotQueryMessage
+	return q.QueryContext(context.Background(), f, QueryOptions{})
+}
+
+// QueryOptions specifies when QueryContext may return before its deadline.
+type QueryOptions struct {
+	// Count finishes the query once the number of responses reaches it.
+	Count int
+	// Addresses finishes the query once all of them have responded.
+	// It defaults to the address specified by SetAddress.
+	Addresses []net.UDPAddr
+}
+
+func (o QueryOptions) satisfied(data []receiverData) bool {
+	if o.Count > 0 && len(data) >= o.Count {
+		return true
+	}
+	if len(o.Addresses) == 0 {
+		return false
+	}
+	for _, addr := range o.Addresses {
+		if !slices.ContainsFunc(data, func(d receiverData) bool { return sameHost(d.addr, addr) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// complete reports whether a query needs no retries. A query without
+// conditions is retried only if no device responded.
+func (o QueryOptions) complete(data []receiverData) bool {
+	if o.Count <= 0 && len(o.Addresses) == 0 {
+		return len(data) > 0
 	}
+	return o.satisfied(data)
+}
 
-	udpAddr, err := net.ResolveUDPAddr("udp", BroadcastAddress)
-	if err != nil {
-		return nil, err
+// QueryContext sends a Get frame and collects responses until ctx is done,
+// the timeout expires or opts is satisfied. Responses collected so far are
+// returned along with ctx.Err() if ctx is canceled, or the error if a retry
+// could not be sent.
+//
+// If a retry policy is set by SetRetry, the timeout applies to each attempt
+// and the frame is sent again to the devices which haven't responded. See
+// RetryPolicy.
+func (q QueryBuilder) QueryContext(ctx context.Context, f Frame, opts QueryOptions) ([]QueryResponse, error) {
+	if f.Ehd1 != 0x10 || f.Ehd2 != 0x81 || f.Edata.Esv != ServiceTypeGet {
+		return nil, ErrNotQueryMessage
 	}
 
-	conn, err := net.DialUDP("udp", nil, udpAddr)
-	if err != nil {
-		return nil, err
+	if q.Address != nil && len(opts.Addresses) == 0 {
+		opts.Addresses = []net.UDPAddr{*q.Address}
 	}
-	defer conn.Close()
 
-	receiver := newReceiver(f.Tid)
-	q.controller.receivers.Add(receiver)
-	defer q.controller.receivers.Remove(receiver)
+	receiver := newReceiver(f.Tid, q.Address, ServiceTypeGetRes, ServiceTypeGetSna)
+	q.controller.dispatcher.Add(receiver)
+	defer q.controller.dispatcher.Remove(receiver)
 
-	frameBytes, err := f.Serialize()
-	if err != nil {
+	if err := q.controller.send(q.Address, f); err != nil {
 		return nil, err
 	}
-	_, err = conn.Write(frameBytes)
-	if err != nil {
-		return nil, err
+
+	err := q.wait(ctx, receiver, opts)
+	for retry := 1; err == nil && retry <= q.Retry.Count && !opts.complete(receiver.received()); retry++ {
+		if err = q.Retry.sleep(ctx, retry); err != nil {
+			break
+		}
+		if err := q.resend(f, opts, receiver.received()); err != nil {
+			return collectResponses(receiver), err
+		}
+		err = q.wait(ctx, receiver, opts)
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		// the deadline of ctx just finishes the query
+		err = nil
+	}
+
+	responses := collectResponses(receiver)
+	if err == nil && q.Retry.RetryUnavailable {
+		q.retryUnavailable(ctx, f, responses)
 	}
 
-	time.Sleep(q.Timeout)
+	return responses, err
+}
 
+func collectResponses(receiver *receiver) []QueryResponse {
 	responses := []QueryResponse{}
-	for _, a := range receiver.data {
+	for _, a := range receiver.received() {
 		responses = append(responses, QueryResponse{
 			Addr:  a.addr,
 			Frame: a.data,
 		})
 	}
-
-	return responses, nil
+	return responses
 }
 
-type QueryResponse struct {
-	Addr  net.UDPAddr
-	Frame Frame
-}
+// wait waits for responses until opts is satisfied or the timeout expires,
+// and returns ctx.Err() if ctx is done.
+func (q QueryBuilder) wait(ctx context.Context, receiver *receiver, opts QueryOptions) error {
+	attemptCtx, cancel := q.attemptContext(ctx)
+	defer cancel()
 
-type responseReceiver interface {
-	Accept(addr net.UDPAddr, data Frame) bool
+	for !opts.satisfied(receiver.received()) {
+		select {
+		case <-receiver.notify:
+		case <-attemptCtx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
 }
 
-type receiverData struct {
-	addr net.UDPAddr
-	data Frame
+// attemptContext returns a context which is done when an attempt of a query
+// times out. See Timeout.
+func (q QueryBuilder) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if q.Timeout > 0 {
+		return context.WithTimeout(ctx, q.Timeout)
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultTimeout)
 }
 
-type receiver struct {
-	tid  uint16
-	data []receiverData
+// waitFirst waits for the first response until the attempt times out. It
+// returns ctx.Err() if ctx is canceled, or ErrNoResponse.
+func (q QueryBuilder) waitFirst(ctx context.Context, receiver *receiver) (receiverData, error) {
+	attemptCtx, cancel := q.attemptContext(ctx)
+	defer cancel()
+
+	select {
+	case <-receiver.notify:
+		return receiver.received()[0], nil
+	case <-attemptCtx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return receiverData{}, ctx.Err()
+		}
+		return receiverData{}, ErrNoResponse
+	}
 }
 
-func newReceiver(tid uint16) *receiver {
-	return &receiver{tid: tid, data: []receiverData{}}
+// Set is SetContext with context.Background.
+func (q QueryBuilder) Set(f Frame) (SetResponse, error) {
+	return q.SetContext(context.Background(), f)
 }
 
-func (r *receiver) Accept(addr net.UDPAddr, frame Frame) bool {
-	if frame.Tid != r.tid {
-		return false
+// SetContext sends a SetC frame to the address specified by SetAddress and
+// waits for the Set_Res or SetC_SNA response until ctx is done or the
+// timeout expires. See Timeout.
+//
+// If a retry policy is set by SetRetry, the frame is sent again with the
+// same TID while the device doesn't respond.
+func (q QueryBuilder) SetContext(ctx context.Context, f Frame) (SetResponse, error) {
+	if f.Ehd1 != 0x10 || f.Ehd2 != 0x81 || f.Edata.Esv != ServiceTypeSetC {
+		return SetResponse{}, ErrUnsupportedMessage
+	}
+	if q.Address == nil {
+		return SetResponse{}, ErrNoAddress
 	}
-	r.data = append(r.data, receiverData{addr, frame})
-	return true
-}
 
-type receiverCollection struct {
-	m         sync.Mutex
-	receivers []responseReceiver
-}
+	receiver := newReceiver(f.Tid, q.Address, ServiceTypeSetReq, ServiceTypeSetCSna)
+	q.controller.dispatcher.Add(receiver)
+	defer q.controller.dispatcher.Remove(receiver)
 
-func newReceiverCollection() receiverCollection {
-	return receiverCollection{
-		receivers: []responseReceiver{},
+	if err := q.controller.send(q.Address, f); err != nil {
+		return SetResponse{}, err
+	}
+
+	res, err := q.waitFirst(ctx, receiver)
+	for retry := 1; err == ErrNoResponse && retry <= q.Retry.Count; retry++ {
+		if sleepErr := q.Retry.sleep(ctx, retry); sleepErr != nil {
+			if errors.Is(sleepErr, context.Canceled) {
+				err = sleepErr
+			}
+			break
+		}
+		if err := q.controller.send(q.Address, f); err != nil {
+			return SetResponse{}, err
+		}
+		res, err = q.waitFirst(ctx, receiver)
+	}
+	if err != nil {
+		return SetResponse{}, err
 	}
+	return newSetResponse(res.addr, res.data), nil
 }
 
-func (c *receiverCollection) Add(r responseReceiver) {
-	c.m.Lock()
-	defer c.m.Unlock()
+type QueryResponse struct {
+	Addr  net.UDPAddr
+	Frame Frame
+}
 
-	c.receivers = append(c.receivers, r)
+// SetGet is SetGetContext with context.Background.
+func (q QueryBuilder) SetGet(f Frame) (QueryResponse, error) {
+	return q.SetGetContext(context.Background(), f)
 }
 
-func (c *receiverCollection) Remove(target responseReceiver) {
-	c.m.Lock()
-	defer c.m.Unlock()
+// SetGetContext sends a SetGet frame to the address specified by SetAddress
+// and waits for the SetGet_Res or SetGet_SNA response until ctx is done or
+// the timeout expires. See Timeout.
+func (q QueryBuilder) SetGetContext(ctx context.Context, f Frame) (QueryResponse, error) {
+	if f.Ehd1 != 0x10 || f.Ehd2 != 0x81 || f.Edata.Esv != ServiceTypeSetGet {
+		return QueryResponse{}, ErrUnsupportedMessage
+	}
+	if q.Address == nil {
+		return QueryResponse{}, ErrNoAddress
+	}
 
-	newSlice := []responseReceiver{}
-	for _, r := range c.receivers {
-		if r != target {
-			newSlice = append(newSlice, r)
-		}
+	receiver := newReceiver(f.Tid, q.Address, ServiceTypeSetGetRes, ServiceTypeSetGetSna)
+	q.controller.dispatcher.Add(receiver)
+	defer q.controller.dispatcher.Remove(receiver)
+
+	if err := q.controller.send(q.Address, f); err != nil {
+		return QueryResponse{}, err
+	}
+
+	res, err := q.waitFirst(ctx, receiver)
+	if err != nil {
+		return QueryResponse{}, err
 	}
-	c.receivers = newSlice
+	return QueryResponse{Addr: res.addr, Frame: res.data}, nil
 }
 
-func (c *receiverCollection) AcceptAll(addr net.UDPAddr, f Frame) {
-	c.m.Lock()
-	defer c.m.Unlock()
+type SetResponse struct {
+	Addr     net.UDPAddr
+	Frame    Frame
+	Accepted []byte
+	Rejected []byte
+}
 
-	for _, r := range c.receivers {
-		r.Accept(addr, f)
+// newSetResponse classifies the properties of a Set_Res or SetC_SNA frame.
+// Accepted properties are echoed back with an empty EDT, while rejected ones
+// carry the requested EDT.
+func newSetResponse(addr net.UDPAddr, f Frame) SetResponse {
+	res := SetResponse{
+		Addr:     addr,
+		Frame:    f,
+		Accepted: []byte{},
+		Rejected: []byte{},
+	}
+	for _, prop := range f.Edata.Properties {
+		if len(prop.Edt) == 0 {
+			res.Accepted = append(res.Accepted, prop.Epc)
+		} else {
+			res.Rejected = append(res.Rejected, prop.Epc)
+		}
 	}
+	return res
 }
@@ -0,0 +1,1003 @@
+package echonetlite_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/echonettest"
+)
+
+var (
+	controllerAddr = net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 3610}
+	device1Addr    = net.UDPAddr{IP: net.IPv4(192, 0, 2, 11), Port: 3610}
+	device2Addr    = net.UDPAddr{IP: net.IPv4(192, 0, 2, 12), Port: 3610}
+)
+
+// startDevice starts a controller hosting an air conditioner object.
+func startDevice(t *testing.T, network *echonetlite.MemoryNetwork, addr net.UDPAddr, status byte) *echonetlite.Controller {
+	return echonettest.StartDevice(t, network, addr, &echonetlite.LocalObject{
+		Eoj:        0x013001,
+		Properties: map[byte][]byte{0x80: {status}},
+	})
+}
+
+func receiveFrame(t *testing.T, transport *echonetlite.MemoryTransport) (echonetlite.Frame, net.UDPAddr) {
+	data, addr, err := transport.Receive()
+	if err != nil {
+		t.Fatalf("Receive failure: %v", err)
+	}
+	frame, err := echonetlite.DeserializeFrame(data)
+	if err != nil {
+		t.Fatalf("DeserializeFrame failure: %v", err)
+	}
+	return frame, addr
+}
+
+func sendFrame(t *testing.T, transport *echonetlite.MemoryTransport, addr net.UDPAddr, frame echonetlite.Frame) {
+	data, err := frame.Serialize()
+	if err != nil {
+		t.Fatalf("Serialize failure: %v", err)
+	}
+	if err := transport.Send(addr, data); err != nil {
+		t.Fatalf("Send failure: %v", err)
+	}
+}
+
+// respondOnce answers the next frame transport receives with the frame built
+// by respond. It is called on a goroutine other than the test's one, so it
+// doesn't stop the test on failure.
+func respondOnce(t *testing.T, transport *echonetlite.MemoryTransport, respond func(req echonetlite.Frame) echonetlite.Frame) {
+	data, addr, err := transport.Receive()
+	if err != nil {
+		t.Errorf("Receive failure: %v", err)
+		return
+	}
+	req, err := echonetlite.DeserializeFrame(data)
+	if err != nil {
+		t.Errorf("DeserializeFrame failure: %v", err)
+		return
+	}
+	res, err := respond(req).Serialize()
+	if err != nil {
+		t.Errorf("Serialize failure: %v", err)
+		return
+	}
+	transport.Send(addr, res)
+}
+
+func getFrame(c *echonetlite.Controller, deoj echonetlite.EOJ, epcs ...byte) echonetlite.Frame {
+	f := c.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj:       echonetlite.ObjectController,
+		Deoj:       deoj,
+		Esv:        echonetlite.ServiceTypeGet,
+		Properties: []echonetlite.Property{},
+	}
+	for _, epc := range epcs {
+		f.Edata.Properties = append(f.Edata.Properties, echonetlite.Property{Epc: epc, Edt: []byte{}})
+	}
+	return f
+}
+
+func TestQuery(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	startDevice(t, network, device1Addr, 0x30)
+	startDevice(t, network, device2Addr, 0x31)
+
+	t.Run("Multicast", func(t *testing.T) {
+		responses, err := c.QueryBuilder().SetTimeout(100 * time.Millisecond).Query(getFrame(c, 0x013001, 0x80))
+		if err != nil {
+			t.Fatalf("Query failure: %v", err)
+		}
+		if len(responses) != 2 {
+			t.Fatalf("Query failure: %d responses", len(responses))
+		}
+		for _, res := range responses {
+			if res.Frame.Edata.Esv != echonetlite.ServiceTypeGetRes || res.Frame.Edata.Seoj != 0x013001 {
+				t.Errorf("Query failure: %+v", res)
+			}
+		}
+	})
+
+	t.Run("Unicast", func(t *testing.T) {
+		start := time.Now()
+		responses, err := c.QueryBuilder().SetTimeout(10 * time.Second).SetAddress(device2Addr).Query(getFrame(c, 0x013001, 0x80))
+		if err != nil {
+			t.Fatalf("Query failure: %v", err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("Query did not return early")
+		}
+		if len(responses) != 1 || !responses[0].Addr.IP.Equal(device2Addr.IP) {
+			t.Fatalf("Query failure: %+v", responses)
+		}
+		if !reflect.DeepEqual(responses[0].Frame.Edata.Properties, []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x31}}}) {
+			t.Errorf("Query failure: %+v", responses[0].Frame.Edata.Properties)
+		}
+	})
+
+	t.Run("Count", func(t *testing.T) {
+		responses, err := c.QueryBuilder().QueryContext(context.Background(), getFrame(c, 0x013001, 0x80), echonetlite.QueryOptions{Count: 2})
+		if err != nil || len(responses) != 2 {
+			t.Fatalf("QueryContext failure: %d responses, %v", len(responses), err)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := c.QueryBuilder().QueryContext(ctx, getFrame(c, 0x013001, 0x80), echonetlite.QueryOptions{})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("QueryContext failure: %v", err)
+		}
+	})
+
+	t.Run("NotQueryMessage", func(t *testing.T) {
+		f := getFrame(c, 0x013001, 0x80)
+		f.Edata.Esv = echonetlite.ServiceTypeSetI
+		if _, err := c.QueryBuilder().Query(f); err != echonetlite.ErrNotQueryMessage {
+			t.Errorf("Query failure: %v", err)
+		}
+	})
+}
+
+func TestQueryUnicast(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device1 := network.Listen(device1Addr)
+	defer device1.Close()
+	device2 := network.Listen(device2Addr)
+	defer device2.Close()
+
+	f := getFrame(c, 0x013001, 0x80)
+	go respondOnce(t, device1, func(req echonetlite.Frame) echonetlite.Frame {
+		// a response from another device with the same TID is ignored
+		spoofed := req
+		spoofed.Edata.Seoj, spoofed.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+		spoofed.Edata.Esv = echonetlite.ServiceTypeGetRes
+		spoofed.Edata.Properties = []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x31}}}
+		if data, err := spoofed.Serialize(); err == nil {
+			device2.Send(controllerAddr, data)
+		}
+
+		res := spoofed
+		res.Edata.Properties = []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}}
+		return res
+	})
+
+	responses, err := c.QueryBuilder().SetTimeout(100*time.Millisecond).SetAddress(device1Addr).QueryContext(context.Background(), f, echonetlite.QueryOptions{Count: 2})
+	if err != nil {
+		t.Fatalf("Query failure: %v", err)
+	}
+	if len(responses) != 1 || !responses[0].Addr.IP.Equal(device1Addr.IP) {
+		t.Fatalf("Query failure: %+v", responses)
+	}
+	if !reflect.DeepEqual(responses[0].Frame.Edata.Properties, []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}}) {
+		t.Errorf("Query failure: %+v", responses[0].Frame.Edata.Properties)
+	}
+}
+
+func TestQueryZone(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	addr := net.UDPAddr{IP: net.ParseIP("fe80::1"), Port: 3610, Zone: "eth0"}
+	c := echonettest.StartController(t, network, addr)
+	deviceAddr := net.UDPAddr{IP: net.ParseIP("fe80::11"), Port: 3610, Zone: "eth0"}
+	device := network.Listen(deviceAddr)
+	defer device.Close()
+	// the same link-local address on another link
+	otherAddr := deviceAddr
+	otherAddr.Zone = "eth1"
+	other := network.Listen(otherAddr)
+	defer other.Close()
+
+	go respondOnce(t, device, func(req echonetlite.Frame) echonetlite.Frame {
+		res := req
+		res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+		res.Edata.Esv = echonetlite.ServiceTypeGetRes
+		res.Edata.Properties = []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x31}}}
+		if data, err := res.Serialize(); err == nil {
+			other.Send(addr, data)
+		}
+		res.Edata.Properties = []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}}
+		return res
+	})
+
+	responses, err := c.QueryBuilder().SetTimeout(100*time.Millisecond).SetAddress(deviceAddr).QueryContext(context.Background(), getFrame(c, 0x013001, 0x80), echonetlite.QueryOptions{Count: 2})
+	if err != nil {
+		t.Fatalf("Query failure: %v", err)
+	}
+	if len(responses) != 1 || responses[0].Addr.Zone != "eth0" {
+		t.Fatalf("Query failure: %+v", responses)
+	}
+	if !reflect.DeepEqual(responses[0].Frame.Edata.Properties, []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}}) {
+		t.Errorf("Query failure: %+v", responses[0].Frame.Edata.Properties)
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device1 := network.Listen(device1Addr)
+	defer device1.Close()
+
+	setFrame := func() echonetlite.Frame {
+		f := c.CreateFrame()
+		f.Edata = echonetlite.SpecifiedMessage{
+			Seoj:       echonetlite.ObjectController,
+			Deoj:       0x013001,
+			Esv:        echonetlite.ServiceTypeSetC,
+			Properties: []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}},
+		}
+		return f
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		start := time.Now()
+		responses, err := c.QueryBuilder().Query(getFrame(c, 0x013001, 0x80))
+		if err != nil || len(responses) != 0 {
+			t.Fatalf("Query failure: %+v, %v", responses, err)
+		}
+		if elapsed := time.Since(start); elapsed < echonetlite.DefaultTimeout || elapsed > 3*echonetlite.DefaultTimeout {
+			t.Errorf("Query did not wait for DefaultTimeout: %v", elapsed)
+		}
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := c.QueryBuilder().QueryContext(ctx, getFrame(c, 0x013001, 0x80), echonetlite.QueryOptions{}); err != nil {
+			t.Fatalf("QueryContext failure: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > echonetlite.DefaultTimeout {
+			t.Errorf("QueryContext did not use the deadline of ctx: %v", elapsed)
+		}
+
+		start = time.Now()
+		if _, err := c.QueryBuilder().SetAddress(device1Addr).SetContext(ctx, setFrame()); err != echonetlite.ErrNoResponse {
+			t.Errorf("SetContext failure: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > echonetlite.DefaultTimeout {
+			t.Errorf("SetContext did not use the deadline of ctx: %v", elapsed)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := c.QueryBuilder().SetTimeout(10*time.Second).SetAddress(device1Addr).SetContext(ctx, setFrame()); !errors.Is(err, context.Canceled) {
+			t.Errorf("SetContext failure: %v", err)
+		}
+	})
+}
+
+func TestQueryConcurrent(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	startDevice(t, network, device1Addr, 0x30)
+	startDevice(t, network, device2Addr, 0x31)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			addr, status := device1Addr, byte(0x30)
+			if i%2 == 1 {
+				addr, status = device2Addr, 0x31
+			}
+			for j := 0; j < 5; j++ {
+				responses, err := c.QueryBuilder().SetTimeout(5 * time.Second).SetAddress(addr).Query(getFrame(c, 0x013001, 0x80))
+				if err != nil || len(responses) != 1 {
+					t.Errorf("Query failure: %+v, %v", responses, err)
+					return
+				}
+				if !reflect.DeepEqual(responses[0].Frame.Edata.Properties, []echonetlite.Property{{Epc: 0x80, Edt: []byte{status}}}) {
+					t.Errorf("Query failure: %+v", responses[0])
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestQueryRetry(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	t.Cleanup(func() { device.Close() })
+	policy := echonetlite.RetryPolicy{Count: 2, Backoff: 10 * time.Millisecond, Jitter: 0.5, RetryUnavailable: true}
+
+	respond := func(esv echonetlite.ServiceType, properties ...echonetlite.Property) func(req echonetlite.Frame) echonetlite.Frame {
+		return func(req echonetlite.Frame) echonetlite.Frame {
+			res := req
+			res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+			res.Edata.Esv = esv
+			res.Edata.Properties = properties
+			return res
+		}
+	}
+
+	t.Run("Lost", func(t *testing.T) {
+		f := getFrame(c, 0x013001, 0x80)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			// the first request is lost
+			if _, _, err := device.Receive(); err != nil {
+				t.Errorf("Receive failure: %v", err)
+				return
+			}
+			respondOnce(t, device, func(req echonetlite.Frame) echonetlite.Frame {
+				if req.Tid != f.Tid {
+					t.Errorf("TID is not reused: %d, %d", req.Tid, f.Tid)
+				}
+				return respond(echonetlite.ServiceTypeGetRes, echonetlite.Property{Epc: 0x80, Edt: []byte{0x30}})(req)
+			})
+		}()
+
+		responses, err := c.QueryBuilder().SetTimeout(50 * time.Millisecond).SetAddress(device1Addr).SetRetry(policy).Query(f)
+		<-done
+		if err != nil || len(responses) != 1 || responses[0].Frame.Edata.Esv != echonetlite.ServiceTypeGetRes {
+			t.Fatalf("Query failure: %+v, %v", responses, err)
+		}
+	})
+
+	t.Run("Unavailable", func(t *testing.T) {
+		f := getFrame(c, 0x013001, 0x80, 0xb0)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			respondOnce(t, device, respond(echonetlite.ServiceTypeGetSna,
+				echonetlite.Property{Epc: 0x80, Edt: []byte{0x30}},
+				echonetlite.Property{Epc: 0xb0, Edt: []byte{}},
+			))
+			respondOnce(t, device, func(req echonetlite.Frame) echonetlite.Frame {
+				if req.Tid == f.Tid || len(req.Edata.Properties) != 1 || req.Edata.Properties[0].Epc != 0xb0 {
+					t.Errorf("unexpected re-query: %+v", req)
+				}
+				return respond(echonetlite.ServiceTypeGetRes, echonetlite.Property{Epc: 0xb0, Edt: []byte{0x42}})(req)
+			})
+		}()
+
+		responses, err := c.QueryBuilder().SetTimeout(50 * time.Millisecond).SetAddress(device1Addr).SetRetry(policy).Query(f)
+		<-done
+		if err != nil || len(responses) != 1 {
+			t.Fatalf("Query failure: %+v, %v", responses, err)
+		}
+		expect := []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}, {Epc: 0xb0, Edt: []byte{0x42}}}
+		if responses[0].Frame.Edata.Esv != echonetlite.ServiceTypeGetRes || !reflect.DeepEqual(responses[0].Frame.Edata.Properties, expect) {
+			t.Errorf("Query failure: %+v", responses[0].Frame.Edata)
+		}
+	})
+
+	t.Run("NoResponse", func(t *testing.T) {
+		start := time.Now()
+		responses, err := c.QueryBuilder().SetTimeout(20 * time.Millisecond).SetAddress(device2Addr).SetRetry(policy).Query(getFrame(c, 0x013001, 0x80))
+		if err != nil || len(responses) != 0 {
+			t.Fatalf("Query failure: %+v, %v", responses, err)
+		}
+		// 3 attempts and 2 delays
+		if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+			t.Errorf("Query did not retry: %v", elapsed)
+		}
+	})
+
+	t.Run("MaxBackoff", func(t *testing.T) {
+		start := time.Now()
+		policy := echonetlite.RetryPolicy{Count: 1, Backoff: 10 * time.Second, MaxBackoff: 20 * time.Millisecond}
+		if _, err := c.QueryBuilder().SetTimeout(20 * time.Millisecond).SetAddress(device2Addr).SetRetry(policy).Query(getFrame(c, 0x013001, 0x80)); err != nil {
+			t.Fatalf("Query failure: %v", err)
+		}
+		// the first delay is capped too
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Query did not cap the delay: %v", elapsed)
+		}
+	})
+
+	t.Run("SendFailure", func(t *testing.T) {
+		respondingAddr := net.UDPAddr{IP: net.IPv4(192, 0, 2, 13), Port: 3610}
+		startDevice(t, network, respondingAddr, 0x30)
+		go func() {
+			// the controller stops before the retry
+			time.Sleep(50 * time.Millisecond)
+			c.Stop()
+		}()
+
+		policy := echonetlite.RetryPolicy{Count: 1, Backoff: 100 * time.Millisecond}
+		opts := echonetlite.QueryOptions{Addresses: []net.UDPAddr{respondingAddr, device2Addr}}
+		responses, err := c.QueryBuilder().SetTimeout(20*time.Millisecond).SetRetry(policy).QueryContext(context.Background(), getFrame(c, 0x013001, 0x80), opts)
+		if err != echonetlite.ErrNotStarted {
+			t.Errorf("QueryContext failure: %v", err)
+		}
+		if len(responses) != 1 || !responses[0].Addr.IP.Equal(respondingAddr.IP) {
+			t.Errorf("QueryContext failure: %+v", responses)
+		}
+	})
+}
+
+func TestExecute(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	defer device.Close()
+
+	f := c.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj:       echonetlite.ObjectController,
+		Deoj:       0x013001,
+		Esv:        echonetlite.ServiceTypeSetI,
+		Properties: []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}},
+	}
+	if err := c.Execute(device1Addr, f); err != nil {
+		t.Fatalf("Execute failure: %v", err)
+	}
+
+	actual, addr := receiveFrame(t, device)
+	if !reflect.DeepEqual(actual, f) || !addr.IP.Equal(controllerAddr.IP) {
+		t.Errorf("Execute failure: %+v from %v", actual, addr)
+	}
+}
+
+func TestSet(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	defer device.Close()
+
+	go respondOnce(t, device, func(req echonetlite.Frame) echonetlite.Frame {
+		res := req
+		res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+		res.Edata.Esv = echonetlite.ServiceTypeSetCSna
+		res.Edata.Properties = []echonetlite.Property{
+			{Epc: 0x80, Edt: []byte{}},
+			{Epc: 0xb3, Edt: []byte{0x64}},
+		}
+		return res
+	})
+
+	f := c.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj: echonetlite.ObjectController,
+		Deoj: 0x013001,
+		Esv:  echonetlite.ServiceTypeSetC,
+		Properties: []echonetlite.Property{
+			{Epc: 0x80, Edt: []byte{0x30}},
+			{Epc: 0xb3, Edt: []byte{0x64}},
+		},
+	}
+	res, err := c.QueryBuilder().SetTimeout(5 * time.Second).SetAddress(device1Addr).Set(f)
+	if err != nil {
+		t.Fatalf("Set failure: %v", err)
+	}
+	if !reflect.DeepEqual(res.Accepted, []byte{0x80}) || !reflect.DeepEqual(res.Rejected, []byte{0xb3}) {
+		t.Errorf("Set failure: %+v", res)
+	}
+
+	if _, err := c.QueryBuilder().Set(f); err != echonetlite.ErrNoAddress {
+		t.Errorf("Set failure: %v", err)
+	}
+}
+
+func TestSetNoResponse(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device1 := network.Listen(device1Addr)
+	defer device1.Close()
+	device2 := network.Listen(device2Addr)
+	defer device2.Close()
+
+	f := c.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj:       echonetlite.ObjectController,
+		Deoj:       0x013001,
+		Esv:        echonetlite.ServiceTypeSetC,
+		Properties: []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}},
+	}
+	go respondOnce(t, device1, func(req echonetlite.Frame) echonetlite.Frame {
+		// only another device responds
+		res := req
+		res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+		res.Edata.Esv = echonetlite.ServiceTypeSetReq
+		res.Edata.Properties = []echonetlite.Property{{Epc: 0x80, Edt: []byte{}}}
+		if data, err := res.Serialize(); err == nil {
+			device2.Send(controllerAddr, data)
+		}
+		// a response to another request
+		res.Tid++
+		return res
+	})
+	if _, err := c.QueryBuilder().SetTimeout(50 * time.Millisecond).SetAddress(device1Addr).Set(f); err != echonetlite.ErrNoResponse {
+		t.Errorf("Set failure: %v", err)
+	}
+
+	f.Edata.Esv = echonetlite.ServiceTypeSetI
+	if _, err := c.QueryBuilder().SetTimeout(50 * time.Millisecond).SetAddress(device1Addr).Set(f); err != echonetlite.ErrUnsupportedMessage {
+		t.Errorf("Set failure for SetI: %v", err)
+	}
+}
+
+func TestSetRetry(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	defer device.Close()
+
+	f := c.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj:       echonetlite.ObjectController,
+		Deoj:       0x013001,
+		Esv:        echonetlite.ServiceTypeSetC,
+		Properties: []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x30}}},
+	}
+	go func() {
+		// the first request is lost
+		if _, _, err := device.Receive(); err != nil {
+			t.Errorf("Receive failure: %v", err)
+			return
+		}
+		respondOnce(t, device, func(req echonetlite.Frame) echonetlite.Frame {
+			if req.Tid != f.Tid {
+				t.Errorf("Set failure: TID %d, expected %d", req.Tid, f.Tid)
+			}
+			res := req
+			res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+			res.Edata.Esv = echonetlite.ServiceTypeSetReq
+			res.Edata.Properties = []echonetlite.Property{{Epc: 0x80, Edt: []byte{}}}
+			return res
+		})
+	}()
+
+	retry := echonetlite.RetryPolicy{Count: 1, Backoff: 10 * time.Millisecond}
+	res, err := c.QueryBuilder().SetTimeout(50 * time.Millisecond).SetAddress(device1Addr).SetRetry(retry).Set(f)
+	if err != nil || !reflect.DeepEqual(res.Accepted, []byte{0x80}) {
+		t.Errorf("Set failure: %+v, %v", res, err)
+	}
+
+	// the device doesn't respond to any of the attempts
+	if _, err := c.QueryBuilder().SetTimeout(20 * time.Millisecond).SetAddress(device1Addr).SetRetry(retry).Set(f); err != echonetlite.ErrNoResponse {
+		t.Errorf("Set failure: %v", err)
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	defer device.Close()
+
+	go respondOnce(t, device, func(req echonetlite.Frame) echonetlite.Frame {
+		res := req
+		res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+		res.Edata.Esv = echonetlite.ServiceTypeSetGetRes
+		res.Edata.Properties = []echonetlite.Property{{Epc: 0xb3, Edt: []byte{}}}
+		res.Edata.GetProperties = []echonetlite.Property{{Epc: 0xbb, Edt: []byte{0x1b}}}
+		return res
+	})
+
+	f := c.CreateFrame()
+	f.Edata = echonetlite.SpecifiedMessage{
+		Seoj:          echonetlite.ObjectController,
+		Deoj:          0x013001,
+		Esv:           echonetlite.ServiceTypeSetGet,
+		Properties:    []echonetlite.Property{{Epc: 0xb3, Edt: []byte{0x1a}}},
+		GetProperties: []echonetlite.Property{{Epc: 0xbb, Edt: []byte{}}},
+	}
+	res, err := c.QueryBuilder().SetTimeout(5 * time.Second).SetAddress(device1Addr).SetGet(f)
+	if err != nil {
+		t.Fatalf("SetGet failure: %v", err)
+	}
+	if !reflect.DeepEqual(res.Frame.Edata.GetProperties, []echonetlite.Property{{Epc: 0xbb, Edt: []byte{0x1b}}}) {
+		t.Errorf("SetGet failure: %+v", res)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	defer device.Close()
+
+	notifications := make(chan echonetlite.Notification, 1)
+	subscription := c.SubscribeChannel(echonetlite.SubscriptionFilter{Seoj: 0x013000, Epcs: []byte{0x80}}, notifications)
+	defer subscription.Unsubscribe()
+
+	infc := echonetlite.Frame{
+		Ehd1: 0x10,
+		Ehd2: 0x81,
+		Tid:  0x1234,
+		Edata: echonetlite.SpecifiedMessage{
+			Seoj:       0x013001,
+			Deoj:       echonetlite.ObjectController,
+			Esv:        echonetlite.ServiceTypeInfC,
+			Properties: []echonetlite.Property{{Epc: 0x80, Edt: []byte{0x31}}},
+		},
+	}
+	sendFrame(t, device, controllerAddr, infc)
+
+	select {
+	case n := <-notifications:
+		if !reflect.DeepEqual(n.Frame, infc) || !n.Addr.IP.Equal(device1Addr.IP) {
+			t.Errorf("Subscribe failure: %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Subscribe failure: no notification")
+	}
+
+	res, _ := receiveFrame(t, device)
+	expect := echonetlite.Frame{
+		Ehd1: 0x10,
+		Ehd2: 0x81,
+		Tid:  0x1234,
+		Edata: echonetlite.SpecifiedMessage{
+			Seoj:       echonetlite.ObjectController,
+			Deoj:       0x013001,
+			Esv:        echonetlite.ServiceTypeInfCRes,
+			Properties: []echonetlite.Property{{Epc: 0x80, Edt: []byte{}}},
+		},
+	}
+	if !reflect.DeepEqual(res, expect) {
+		t.Errorf("InfCRes failure: %+v", res)
+	}
+}
+
+func TestInfCRes(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	echonettest.StartController(t, network, controllerAddr)
+	device := network.Listen(device1Addr)
+	defer device.Close()
+
+	notification := func(tid uint16, esv echonetlite.ServiceType) echonetlite.Frame {
+		return echonetlite.Frame{
+			Ehd1: 0x10,
+			Ehd2: 0x81,
+			Tid:  tid,
+			Edata: echonetlite.SpecifiedMessage{
+				Seoj: 0x013001,
+				Deoj: echonetlite.ObjectController,
+				Esv:  esv,
+				Properties: []echonetlite.Property{
+					{Epc: 0x80, Edt: []byte{0x30}},
+					{Epc: 0xb0, Edt: []byte{0x42}},
+				},
+			},
+		}
+	}
+	// Inf is not acknowledged, and InfC is acknowledged without subscribers
+	sendFrame(t, device, controllerAddr, notification(1, echonetlite.ServiceTypeInf))
+	sendFrame(t, device, controllerAddr, notification(2, echonetlite.ServiceTypeInfC))
+
+	res, addr := receiveFrame(t, device)
+	if res.Tid != 2 || res.Edata.Esv != echonetlite.ServiceTypeInfCRes || !addr.IP.Equal(controllerAddr.IP) {
+		t.Fatalf("InfCRes failure: %+v from %v", res, addr)
+	}
+	expect := []echonetlite.Property{{Epc: 0x80, Edt: []byte{}}, {Epc: 0xb0, Edt: []byte{}}}
+	if res.Edata.Seoj != echonetlite.ObjectController || res.Edata.Deoj != 0x013001 || !reflect.DeepEqual(res.Edata.Properties, expect) {
+		t.Errorf("InfCRes failure: %+v", res)
+	}
+}
+
+func TestSubscribeFilter(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device1 := network.Listen(device1Addr)
+	defer device1.Close()
+	device2 := network.Listen(device2Addr)
+	defer device2.Close()
+
+	notifications := make(chan echonetlite.Notification, 10)
+	addr := device1Addr
+	subscription := c.Subscribe(echonetlite.SubscriptionFilter{Addr: &addr, Seoj: 0x013000, Epcs: []byte{0x80}}, func(n echonetlite.Notification) {
+		notifications <- n
+	})
+
+	inf := func(tid uint16, esv echonetlite.ServiceType, seoj echonetlite.EOJ, epc byte) echonetlite.Frame {
+		return echonetlite.Frame{
+			Ehd1: 0x10,
+			Ehd2: 0x81,
+			Tid:  tid,
+			Edata: echonetlite.SpecifiedMessage{
+				Seoj:       seoj,
+				Deoj:       echonetlite.ObjectNodeProfile,
+				Esv:        esv,
+				Properties: []echonetlite.Property{{Epc: epc, Edt: []byte{0x30}}},
+			},
+		}
+	}
+	// frames are handled in order, so the first notification is the only
+	// one which matches the filter
+	sendFrame(t, device2, controllerAddr, inf(1, echonetlite.ServiceTypeInf, 0x013001, 0x80))
+	sendFrame(t, device1, controllerAddr, inf(2, echonetlite.ServiceTypeInf, 0x013001, 0xb0))
+	sendFrame(t, device1, controllerAddr, inf(3, echonetlite.ServiceTypeInf, 0x027901, 0x80))
+	sendFrame(t, device1, controllerAddr, inf(4, echonetlite.ServiceTypeGetRes, 0x013001, 0x80))
+	sendFrame(t, device1, controllerAddr, inf(5, echonetlite.ServiceTypeInf, 0x013002, 0x80))
+	select {
+	case n := <-notifications:
+		if n.Frame.Tid != 5 {
+			t.Errorf("Subscribe failure: %+v", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Subscribe failure: no notification")
+	}
+
+	subscription.Unsubscribe()
+	sentinel := make(chan echonetlite.Notification, 1)
+	defer c.SubscribeChannel(echonetlite.SubscriptionFilter{}, sentinel).Unsubscribe()
+	sendFrame(t, device1, controllerAddr, inf(6, echonetlite.ServiceTypeInf, 0x013001, 0x80))
+	select {
+	case <-sentinel:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("SubscribeChannel failure: no notification")
+	}
+	select {
+	case n := <-notifications:
+		t.Errorf("Unsubscribe failure: %+v", n)
+	default:
+	}
+}
+
+func TestNode(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	startDevice(t, network, device1Addr, 0x30)
+	peer := network.Listen(controllerAddr)
+	defer peer.Close()
+
+	req := echonetlite.Frame{
+		Ehd1: 0x10,
+		Ehd2: 0x81,
+		Tid:  0x0001,
+		Edata: echonetlite.SpecifiedMessage{
+			Seoj: echonetlite.ObjectController,
+			Deoj: echonetlite.ObjectNodeProfile,
+			Esv:  echonetlite.ServiceTypeGet,
+			Properties: []echonetlite.Property{
+				{Epc: echonetlite.EpcSelfNodeInstanceListS, Edt: []byte{}},
+				{Epc: echonetlite.EpcSelfNodeClassListS, Edt: []byte{}},
+			},
+		},
+	}
+	sendFrame(t, peer, device1Addr, req)
+
+	res, _ := receiveFrame(t, peer)
+	expect := []echonetlite.Property{
+		{Epc: echonetlite.EpcSelfNodeInstanceListS, Edt: []byte{0x02, 0x05, 0xff, 0x01, 0x01, 0x30, 0x01}},
+		{Epc: echonetlite.EpcSelfNodeClassListS, Edt: []byte{0x02, 0x05, 0xff, 0x01, 0x30}},
+	}
+	if res.Edata.Esv != echonetlite.ServiceTypeGetRes || !reflect.DeepEqual(res.Edata.Properties, expect) {
+		t.Errorf("Node failure: %+v", res)
+	}
+
+	req.Edata.Properties = []echonetlite.Property{{Epc: 0x12, Edt: []byte{}}}
+	sendFrame(t, peer, device1Addr, req)
+	res, _ = receiveFrame(t, peer)
+	if res.Edata.Esv != echonetlite.ServiceTypeGetSna {
+		t.Errorf("Node failure: %+v", res)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	startDevice(t, network, device1Addr, 0x30)
+	device2 := startDevice(t, network, device2Addr, 0x30)
+	device2.Node.AddObject(&echonetlite.LocalObject{
+		Eoj:        0x013002,
+		Properties: map[byte][]byte{0x80: {0x30}},
+	})
+
+	nodes, err := c.QueryBuilder().SetTimeout(100 * time.Millisecond).Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover failure: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("Discover failure: %+v", nodes)
+	}
+	for _, node := range nodes {
+		expect := []echonetlite.EOJ{echonetlite.ObjectController, 0x013001}
+		if node.Addr.IP.Equal(device2Addr.IP) {
+			expect = append(expect, 0x013002)
+		}
+		if !reflect.DeepEqual(node.Instances, expect) || node.InstanceCount != len(expect) {
+			t.Errorf("Discover failure: %+v", node)
+		}
+		if !reflect.DeepEqual(node.Classes, []uint16{0x05ff, 0x0130}) {
+			t.Errorf("Discover failure: %+v", node)
+		}
+		if !reflect.DeepEqual(node.InstancesOf(0x0130), expect[1:]) {
+			t.Errorf("InstancesOf failure: %+v", node.InstancesOf(0x0130))
+		}
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	device := echonettest.StartDevice(t, network, device1Addr, &echonetlite.LocalObject{
+		Eoj:          0x013001,
+		Properties:   map[byte][]byte{0x80: {0x30}},
+		SetEpcs:      []byte{0x80},
+		AnnounceEpcs: []byte{0x80},
+	})
+
+	builder := c.QueryBuilder().SetTimeout(100 * time.Millisecond).SetAddress(device1Addr)
+	caps, err := builder.Capabilities(context.Background(), 0x013001)
+	if err != nil {
+		t.Fatalf("Capabilities failure: %v", err)
+	}
+	if !reflect.DeepEqual(caps.Get, []byte{0x80, 0x9d, 0x9e, 0x9f}) || !caps.CanSet(0x80) || !caps.CanAnnounce(0x80) {
+		t.Errorf("Capabilities failure: %+v", caps)
+	}
+	if epcs := caps.FilterGet([]byte{0x80, 0xb0}); !reflect.DeepEqual(epcs, []byte{0x80}) {
+		t.Errorf("FilterGet failure: %v", epcs)
+	}
+
+	// cached capabilities are returned without a query
+	device.Stop()
+	if cached, err := builder.Capabilities(context.Background(), 0x013001); err != nil || !reflect.DeepEqual(cached, caps) {
+		t.Errorf("Capabilities failure: %+v, %v", cached, err)
+	}
+
+	c.ForgetCapabilities(device1Addr, 0x013001)
+	if _, err := builder.Capabilities(context.Background(), 0x013001); !errors.Is(err, echonetlite.ErrNoResponse) {
+		t.Errorf("Capabilities failure: %v", err)
+	}
+}
+
+// frameCounter counts frames the controller sends by the DEOJ.
+type frameCounter struct {
+	m     sync.Mutex
+	deojs map[echonetlite.EOJ]int
+}
+
+func (c *frameCounter) WritePacket(p echonetlite.CapturedPacket) error {
+	f, err := echonetlite.DeserializeFrame(p.Data)
+	if err != nil || p.Direction != echonetlite.DirectionOutbound {
+		return err
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	if c.deojs == nil {
+		c.deojs = map[echonetlite.EOJ]int{}
+	}
+	c.deojs[f.Edata.Deoj]++
+	return nil
+}
+
+func (c *frameCounter) count(deoj echonetlite.EOJ) int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.deojs[deoj]
+}
+
+func TestGetProperties(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	counter := &frameCounter{}
+	c := echonetlite.NewController()
+	c.Transport = network.Listen(controllerAddr)
+	c.Capture = counter
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failure: %v", err)
+	}
+	defer c.Stop()
+
+	t.Run("Instances", func(t *testing.T) {
+		startDevice(t, network, device1Addr, 0x30)
+		for i := 0; i < 2; i++ {
+			responses, err := c.QueryBuilder().SetTimeout(100*time.Millisecond).SetAddress(device1Addr).GetProperties(context.Background(), 0x013000, []byte{0x80})
+			if err != nil || len(responses) != 1 {
+				t.Fatalf("GetProperties failure: %+v, %v", responses, err)
+			}
+		}
+		// the number of instances is discovered once
+		if n := counter.count(echonetlite.ObjectNodeProfile); n != 1 {
+			t.Errorf("GetProperties failure: %d discoveries", n)
+		}
+	})
+
+	t.Run("NoNode", func(t *testing.T) {
+		addr := net.UDPAddr{IP: net.IPv4(192, 0, 2, 30), Port: 3610}
+		device := network.Listen(addr)
+		defer device.Close()
+		discoveries := counter.count(echonetlite.ObjectNodeProfile)
+		for i := 0; i < 2; i++ {
+			if _, err := c.QueryBuilder().SetTimeout(50*time.Millisecond).SetAddress(addr).GetProperties(context.Background(), 0x013000, []byte{0x80}); err != nil {
+				t.Fatalf("GetProperties failure: %v", err)
+			}
+		}
+		// a device which didn't respond is discovered again
+		if n := counter.count(echonetlite.ObjectNodeProfile) - discoveries; n != 2 {
+			t.Errorf("GetProperties failure: %d discoveries", n)
+		}
+	})
+
+	t.Run("NoPropertyMap", func(t *testing.T) {
+		device := network.Listen(device2Addr)
+		defer device.Close()
+		for i := 0; i < 2; i++ {
+			responses, err := c.QueryBuilder().SetTimeout(50*time.Millisecond).SetAddress(device2Addr).GetProperties(context.Background(), 0x027901, []byte{0xe0})
+			if err != nil || len(responses) != 0 {
+				t.Fatalf("GetProperties failure: %+v, %v", responses, err)
+			}
+		}
+		// a property map query and two queries of the property
+		if n := counter.count(0x027901); n != 3 {
+			t.Errorf("GetProperties failure: %d requests", n)
+		}
+	})
+
+	t.Run("Partial", func(t *testing.T) {
+		// devices which don't support 0xb0 respond with Get_SNA
+		for i := 0; i < 6; i++ {
+			echonettest.StartDevice(t, network, net.UDPAddr{IP: net.IPv4(192, 0, 2, byte(21+i)), Port: 3610}, &echonetlite.LocalObject{
+				Eoj:        0x013501,
+				Properties: map[byte][]byte{0x80: {0x30}},
+			})
+		}
+		responses, err := c.QueryBuilder().SetTimeout(100*time.Millisecond).GetProperties(context.Background(), 0x013501, []byte{0x80, 0xb0})
+		if err != nil || len(responses) != 6 {
+			t.Fatalf("GetProperties failure: %+v, %v", responses, err)
+		}
+		partial := 0
+		for _, res := range responses {
+			if res.Partial {
+				partial++
+			}
+		}
+		// only 4 devices are queried again with supported EPCs
+		if partial != 2 {
+			t.Errorf("GetProperties failure: %d partial responses", partial)
+		}
+	})
+}
+
+func TestPropertyRequest(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	echonettest.StartDevice(t, network, device1Addr, &echonetlite.LocalObject{
+		Eoj: 0x013001,
+		Properties: map[byte][]byte{
+			0x80: {0x30},
+			0x83: {0xfe, 0x00, 0x00, 0x06, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d},
+			0xb3: {0x1a, 0x00},
+		},
+	})
+
+	base := echonetlite.NewPropertyRequest(c, 0x013001).WithTimeout(100 * time.Millisecond).WithAddress(device1Addr).WithEpc(0x80)
+	// requests extended from the same one don't share EPCs
+	id := base.WithEpc(echonetlite.EpcIdentificationNumber)
+	temperature := base.WithEpc(0xb3)
+
+	responses, err := id.Get(context.Background())
+	if err != nil || len(responses) != 1 {
+		t.Fatalf("Get failure: %+v, %v", responses, err)
+	}
+	if v, err := responses[0].IdentificationNumber(); err != nil || len(v) != 16 || v[15] != 0x0d {
+		t.Errorf("IdentificationNumber failure: %v, %v", v, err)
+	}
+	if _, err := responses[0].Edt(0xb3); !errors.Is(err, echonetlite.ErrNoResponsesForEpc) {
+		t.Errorf("Edt failure: %v", err)
+	}
+
+	responses, err = temperature.Get(context.Background())
+	if err != nil || len(responses) != 1 {
+		t.Fatalf("Get failure: %+v, %v", responses, err)
+	}
+	if _, err := responses[0].IdentificationNumber(); !errors.Is(err, echonetlite.ErrNoResponsesForEpc) {
+		t.Errorf("IdentificationNumber failure: %v", err)
+	}
+	// EDTs must have the exact size
+	if _, err := responses[0].FixedEdt(0xb3, 1); err != echonetlite.ErrUnexpectedValue {
+		t.Errorf("FixedEdt failure: %v", err)
+	}
+	if v, err := responses[0].FixedEdt(0xb3, 2); err != nil || v[0] != 0x1a {
+		t.Errorf("FixedEdt failure: %v, %v", v, err)
+	}
+}
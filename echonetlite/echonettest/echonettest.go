@@ -0,0 +1,72 @@
+// Package echonettest provides helpers for tests of packages built on
+// echonetlite, which run controllers and devices on a MemoryNetwork.
+package echonettest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+// StartController starts a controller listening on addr in network. It is
+// stopped when the test finishes.
+func StartController(t testing.TB, network *echonetlite.MemoryNetwork, addr net.UDPAddr) *echonetlite.Controller {
+	t.Helper()
+	c := echonetlite.NewController()
+	c.Transport = network.Listen(addr)
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failure: %v", err)
+	}
+	t.Cleanup(func() { c.Stop() })
+	return &c
+}
+
+// StartDevice starts a controller like StartController and adds objects to
+// its node, so it responds like a device hosting them.
+func StartDevice(t testing.TB, network *echonetlite.MemoryNetwork, addr net.UDPAddr, objects ...*echonetlite.LocalObject) *echonetlite.Controller {
+	t.Helper()
+	c := StartController(t, network, addr)
+	for _, o := range objects {
+		c.Node.AddObject(o)
+	}
+	return c
+}
+
+// ServeGet answers Get requests to addr in network with the EDTs in
+// properties, like a device which is not an echonetlite.Controller. A request
+// for an EPC missing from properties is answered with Get_SNA, and so are the
+// property maps unless properties has them. It stops when the test finishes.
+func ServeGet(t testing.TB, network *echonetlite.MemoryNetwork, addr net.UDPAddr, properties map[byte][]byte) {
+	t.Helper()
+	transport := network.Listen(addr)
+	t.Cleanup(func() { transport.Close() })
+	go func() {
+		for {
+			data, from, err := transport.Receive()
+			if err != nil {
+				return
+			}
+			req, err := echonetlite.DeserializeFrame(data)
+			if err != nil || req.Edata.Esv != echonetlite.ServiceTypeGet {
+				continue
+			}
+
+			res := req
+			res.Edata.Seoj, res.Edata.Deoj = req.Edata.Deoj, req.Edata.Seoj
+			res.Edata.Esv = echonetlite.ServiceTypeGetRes
+			res.Edata.Properties = []echonetlite.Property{}
+			for _, prop := range req.Edata.Properties {
+				edt, ok := properties[prop.Epc]
+				if !ok {
+					res.Edata.Esv = echonetlite.ServiceTypeGetSna
+					edt = []byte{}
+				}
+				res.Edata.Properties = append(res.Edata.Properties, echonetlite.Property{Epc: prop.Epc, Edt: edt})
+			}
+			if data, err := res.Serialize(); err == nil {
+				transport.Send(from, data)
+			}
+		}
+	}()
+}
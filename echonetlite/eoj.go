@@ -0,0 +1,83 @@
+package echonetlite
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidEOJ = errors.New("invalid eoj")
+)
+
+// EOJ is an ECHONET Lite object code, which consists of a class group code,
+// a class code and an instance code, e.g. 0x013001 for the first instance of
+// home air conditioners. An instance code of 0 addresses all instances of
+// the class.
+type EOJ uint32
+
+func NewEOJ(classGroupCode, classCode, instanceCode byte) EOJ {
+	return EOJ(classGroupCode)<<16 | EOJ(classCode)<<8 | EOJ(instanceCode)
+}
+
+// ParseEOJ parses 6 hexadecimal digits with an optional "0x" prefix, e.g.
+// "013001" or "0x013001", as formatted by EOJ.String.
+func ParseEOJ(s string) (EOJ, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(digits) != 6 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidEOJ, s)
+	}
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidEOJ, s)
+	}
+	return EOJ(value), nil
+}
+
+func (e EOJ) ClassGroupCode() byte {
+	return byte(e >> 16)
+}
+
+func (e EOJ) ClassCode() byte {
+	return byte(e >> 8)
+}
+
+func (e EOJ) InstanceCode() byte {
+	return byte(e)
+}
+
+// Class returns the class group code and the class code, e.g. 0x0130 for
+// home air conditioners.
+func (e EOJ) Class() uint16 {
+	return uint16(e >> 8)
+}
+
+// WithInstance returns the EOJ of the instance of the same class.
+func (e EOJ) WithInstance(instanceCode byte) EOJ {
+	return e&0xffff00 | EOJ(instanceCode)
+}
+
+// Matches reports whether a message addressed to e reaches the object other,
+// i.e. they are the same or e addresses all instances of the class of other.
+func (e EOJ) Matches(other EOJ) bool {
+	return e == other || (e.InstanceCode() == 0 && e.Class() == other.Class())
+}
+
+// ClassName returns the name of the class in ClassNames, or an empty string
+// if it is unknown.
+func (e EOJ) ClassName() string {
+	return ClassNames[e.Class()]
+}
+
+func (e EOJ) String() string {
+	return fmt.Sprintf("0x%06x", uint32(e))
+}
+
+func (e EOJ) bytes() []byte {
+	return []byte{e.ClassGroupCode(), e.ClassCode(), e.InstanceCode()}
+}
+
+func decodeEOJ(data []byte) EOJ {
+	return NewEOJ(data[0], data[1], data[2])
+}
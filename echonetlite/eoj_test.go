@@ -0,0 +1,38 @@
+package echonetlite_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+func TestEOJ(t *testing.T) {
+	eoj := echonetlite.NewEOJ(0x01, 0x30, 0x02)
+	if eoj != 0x013002 {
+		t.Errorf("NewEOJ failure: %v", eoj)
+	}
+	if eoj.ClassGroupCode() != 0x01 || eoj.ClassCode() != 0x30 || eoj.InstanceCode() != 0x02 || eoj.Class() != 0x0130 {
+		t.Errorf("accessor failure: %v", eoj)
+	}
+	if eoj.String() != "0x013002" || eoj.ClassName() != "Home air conditioner" {
+		t.Errorf("String failure: %s, %s", eoj.String(), eoj.ClassName())
+	}
+	if eoj.WithInstance(0) != 0x013000 {
+		t.Errorf("WithInstance failure: %v", eoj.WithInstance(0))
+	}
+	if !eoj.Matches(0x013002) || !eoj.WithInstance(0).Matches(eoj) || eoj.Matches(0x013001) || eoj.Matches(0x013000) {
+		t.Errorf("Matches failure")
+	}
+
+	for _, s := range []string{"013002", "0x013002", eoj.String()} {
+		if parsed, err := echonetlite.ParseEOJ(s); err != nil || parsed != eoj {
+			t.Errorf("ParseEOJ failure: %s, %v, %v", s, parsed, err)
+		}
+	}
+	for _, s := range []string{"", "0130", "0x01300g", "01300201"} {
+		if _, err := echonetlite.ParseEOJ(s); !errors.Is(err, echonetlite.ErrInvalidEOJ) {
+			t.Errorf("ParseEOJ failure: %s, %v", s, err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package echonetlite
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// PropertyFormatters is a registry of functions which decode EDTs of class
+// specific properties into human readable values, keyed by class group codes
+// and class codes. They are used for properties which
+// PropertyValueFormatter doesn't know.
+var PropertyFormatters = map[uint16]map[byte]func(edt []byte) string{
+	0x0130: {
+		0xa0: formatAirflowRate,
+		0xb0: formatEnum(map[byte]string{0x40: "other", 0x41: "auto", 0x42: "cooling", 0x43: "heating", 0x44: "dehumidification", 0x45: "ventilation"}),
+		0xb3: formatUnsigned("°C"),
+		0xb4: formatUnsigned("%"),
+		0xba: formatUnsigned("%"),
+		0xbb: formatSigned("°C"),
+		0xbe: formatSigned("°C"),
+	},
+	0x0ef0: {
+		0x80: formatEnum(map[byte]string{0x30: "on", 0x31: "off"}),
+		0xd3: formatUnsigned(""),
+		0xd4: formatUnsigned(""),
+		0xd5: formatInstanceList,
+		0xd6: formatInstanceList,
+		0xd7: formatClassList,
+	},
+}
+
+var superClassFormatters = map[byte]func(edt []byte) string{
+	0x80: formatEnum(map[byte]string{0x30: "on", 0x31: "off"}),
+	0x84: formatUnsigned("W"),
+	0x85: formatCumulativeEnergy,
+	0x88: formatEnum(map[byte]string{0x41: "fault", 0x42: "no fault"}),
+	0x9b: formatPropertyMap(0x9b),
+	0x9c: formatPropertyMap(0x9c),
+	0x9d: formatPropertyMap(0x9d),
+	0x9e: formatPropertyMap(0x9e),
+	0x9f: formatPropertyMap(0x9f),
+}
+
+func formatEnum(names map[byte]string) func(edt []byte) string {
+	return func(edt []byte) string {
+		if len(edt) != 1 {
+			return ""
+		}
+		return names[edt[0]]
+	}
+}
+
+func formatUnsigned(unit string) func(edt []byte) string {
+	return func(edt []byte) string {
+		if len(edt) < 1 || len(edt) > 4 {
+			return ""
+		}
+		value := uint32(0)
+		for _, b := range edt {
+			value = value<<8 | uint32(b)
+		}
+		return fmt.Sprintf("%d%s", value, unit)
+	}
+}
+
+func formatSigned(unit string) func(edt []byte) string {
+	return func(edt []byte) string {
+		if len(edt) != 1 {
+			return ""
+		}
+		return fmt.Sprintf("%d%s", int8(edt[0]), unit)
+	}
+}
+
+func formatCumulativeEnergy(edt []byte) string {
+	if len(edt) != 4 {
+		return ""
+	}
+	value := uint32(edt[0])<<24 | uint32(edt[1])<<16 | uint32(edt[2])<<8 | uint32(edt[3])
+	return fmt.Sprintf("%.3fkWh", float64(value)/1000)
+}
+
+func formatAirflowRate(edt []byte) string {
+	if len(edt) != 1 {
+		return ""
+	}
+	if edt[0] == 0x41 {
+		return "auto"
+	}
+	if edt[0] >= 0x31 && edt[0] <= 0x38 {
+		return fmt.Sprintf("level %d", edt[0]-0x30)
+	}
+	return ""
+}
+
+func formatPropertyMap(epc byte) func(edt []byte) string {
+	return func(edt []byte) string {
+		epcs, err := GetPropertyMap(Property{Epc: epc, Edt: edt})
+		if err != nil {
+			return ""
+		}
+		slices.Sort(epcs)
+		return fmt.Sprintf("[% x]", epcs)
+	}
+}
+
+func formatInstanceList(edt []byte) string {
+	eojs := []string{}
+	for _, eoj := range parseInstanceList(edt) {
+		eojs = append(eojs, fmt.Sprintf("%06x", uint32(eoj)))
+	}
+	return "[" + strings.Join(eojs, " ") + "]"
+}
+
+func formatClassList(edt []byte) string {
+	classes := []string{}
+	for _, class := range parseClassList(edt) {
+		classes = append(classes, fmt.Sprintf("%04x", class))
+	}
+	return "[" + strings.Join(classes, " ") + "]"
+}
+
+// PropertyValueFormatter decodes EDTs of any property into human readable
+// values, e.g. with the MRA, instead of PropertyFormatters. It returns false
+// for a property which it doesn't know. See mra.Database.Register.
+var PropertyValueFormatter func(eoj EOJ, epc byte, edt []byte) (string, bool)
+
+// FormatPropertyValue decodes edt of epc of eoj into a human readable value.
+// It returns an empty string if the property is unknown or edt is invalid.
+func FormatPropertyValue(eoj EOJ, epc byte, edt []byte) string {
+	if len(edt) == 0 {
+		return ""
+	}
+	if PropertyValueFormatter != nil {
+		if value, ok := PropertyValueFormatter(eoj, epc, edt); ok {
+			return value
+		}
+	}
+	if format, ok := PropertyFormatters[eoj.Class()][epc]; ok {
+		return format(edt)
+	}
+	if format, ok := superClassFormatters[epc]; ok {
+		return format(edt)
+	}
+	return ""
+}
+
+// propertyOwner returns the object which has the properties in a message,
+// i.e. DEOJ for requests and SEOJ for responses and notifications.
+func (m SpecifiedMessage) propertyOwner() EOJ {
+	if m.Esv >= 0x60 && m.Esv <= 0x6f {
+		return m.Deoj
+	}
+	return m.Seoj
+}
+
+func formatEoj(eoj EOJ) string {
+	if name := eoj.ClassName(); name != "" {
+		return fmt.Sprintf("%s (%s)", eoj, name)
+	}
+	return eoj.String()
+}
+
+func formatProperty(eoj EOJ, p Property) string {
+	s := fmt.Sprintf("0x%02x", p.Epc)
+	if name := PropertyName(eoj, p.Epc); name != "" {
+		s += " " + name
+	}
+	if len(p.Edt) > 0 {
+		s += fmt.Sprintf("=0x%x", p.Edt)
+		if value := FormatPropertyValue(eoj, p.Epc, p.Edt); value != "" {
+			s += " (" + value + ")"
+		}
+	}
+	return s
+}
+
+func formatProperties(eoj EOJ, properties []Property) string {
+	s := []string{}
+	for _, p := range properties {
+		s = append(s, formatProperty(eoj, p))
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+// String renders the frame with names of the ESV, EOJs and EPCs, and
+// decoded values of known properties.
+func (f Frame) String() string {
+	m := f.Edata
+	s := fmt.Sprintf("tid=0x%04x seoj=%s deoj=%s esv=%s (0x%02x) properties=%s",
+		f.Tid, formatEoj(m.Seoj), formatEoj(m.Deoj), m.Esv, byte(m.Esv), formatProperties(m.propertyOwner(), m.Properties))
+	if m.Esv.isSetGet() {
+		s += " get_properties=" + formatProperties(m.propertyOwner(), m.GetProperties)
+	}
+	return s
+}
+
+// LogValue renders the frame as a group in structured logs.
+func (f Frame) LogValue() slog.Value {
+	m := f.Edata
+	attrs := []slog.Attr{
+		slog.String("tid", fmt.Sprintf("0x%04x", f.Tid)),
+		slog.String("seoj", formatEoj(m.Seoj)),
+		slog.String("deoj", formatEoj(m.Deoj)),
+		slog.String("esv", m.Esv.String()),
+		slog.String("properties", formatProperties(m.propertyOwner(), m.Properties)),
+	}
+	if m.Esv.isSetGet() {
+		attrs = append(attrs, slog.String("get_properties", formatProperties(m.propertyOwner(), m.GetProperties)))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+type jsonProperty struct {
+	Epc   string `json:"epc"`
+	Name  string `json:"name,omitempty"`
+	Edt   string `json:"edt"`
+	Value string `json:"value,omitempty"`
+}
+
+type jsonFrame struct {
+	Tid           uint16         `json:"tid"`
+	Seoj          string         `json:"seoj"`
+	SeojName      string         `json:"seoj_name,omitempty"`
+	Deoj          string         `json:"deoj"`
+	DeojName      string         `json:"deoj_name,omitempty"`
+	Esv           string         `json:"esv"`
+	EsvName       string         `json:"esv_name"`
+	Properties    []jsonProperty `json:"properties"`
+	GetProperties []jsonProperty `json:"get_properties,omitempty"`
+}
+
+func newJSONProperties(eoj EOJ, properties []Property) []jsonProperty {
+	props := []jsonProperty{}
+	for _, p := range properties {
+		props = append(props, jsonProperty{
+			Epc:   fmt.Sprintf("%02x", p.Epc),
+			Name:  PropertyName(eoj, p.Epc),
+			Edt:   hex.EncodeToString(p.Edt),
+			Value: FormatPropertyValue(eoj, p.Epc, p.Edt),
+		})
+	}
+	return props
+}
+
+// MarshalJSON renders the frame with names like String. Codes are
+// hexadecimal strings.
+func (f Frame) MarshalJSON() ([]byte, error) {
+	m := f.Edata
+	j := jsonFrame{
+		Tid:        f.Tid,
+		Seoj:       fmt.Sprintf("%06x", uint32(m.Seoj)),
+		SeojName:   m.Seoj.ClassName(),
+		Deoj:       fmt.Sprintf("%06x", uint32(m.Deoj)),
+		DeojName:   m.Deoj.ClassName(),
+		Esv:        fmt.Sprintf("%02x", byte(m.Esv)),
+		EsvName:    m.Esv.String(),
+		Properties: newJSONProperties(m.propertyOwner(), m.Properties),
+	}
+	if m.Esv.isSetGet() {
+		j.GetProperties = newJSONProperties(m.propertyOwner(), m.GetProperties)
+	}
+	return json.Marshal(j)
+}
+
+// trace logs a datagram sent or received by the controller if Trace is
+// enabled.
+func (c *Controller) trace(direction Direction, peer net.UDPAddr, data []byte) {
+	if !c.Trace || !c.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+
+	msg := "[trace] received"
+	if direction == DirectionOutbound {
+		msg = "[trace] sent"
+	}
+	frame, err := DeserializeFrame(data)
+	if err != nil {
+		c.Logger.Debug(msg, "addr", peer.String(), "data", hex.EncodeToString(data), "err", err)
+		return
+	}
+	c.Logger.Debug(msg, "addr", peer.String(), "frame", frame)
+}
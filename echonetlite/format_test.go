@@ -0,0 +1,91 @@
+package echonetlite_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"golang.org/x/exp/slog"
+)
+
+var getResFrame = echonetlite.Frame{
+	Ehd1: 0x10,
+	Ehd2: 0x81,
+	Tid:  0x0102,
+	Edata: echonetlite.SpecifiedMessage{
+		Seoj: 0x013001,
+		Deoj: 0x05ff01,
+		Esv:  echonetlite.ServiceTypeGetRes,
+		Properties: []echonetlite.Property{
+			{Epc: 0x80, Edt: []byte{0x30}},
+			{Epc: 0xbb, Edt: []byte{0xfe}},
+			{Epc: 0xf0, Edt: []byte{0x01, 0x02}},
+		},
+	},
+}
+
+func TestFrameString(t *testing.T) {
+	expect := "tid=0x0102 seoj=0x013001 (Home air conditioner) deoj=0x05ff01 (Controller) esv=GetRes (0x72) " +
+		"properties=[0x80 Operation status=0x30 (on), 0xbb Measured value of room temperature=0xfe (-2°C), 0xf0=0x0102]"
+	if s := getResFrame.String(); s != expect {
+		t.Errorf("String failure: %s", s)
+	}
+
+	f := getFrame(&echonetlite.Controller{}, 0x0ef001, 0xd6)
+	if s := f.String(); !strings.Contains(s, "deoj=0x0ef001 (Node profile)") || !strings.Contains(s, "[0xd6 Self-node instance list S]") {
+		t.Errorf("String failure: %s", s)
+	}
+}
+
+func TestFrameJSON(t *testing.T) {
+	data, err := json.Marshal(getResFrame)
+	if err != nil {
+		t.Fatalf("Marshal failure: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failure: %v", err)
+	}
+	if decoded["seoj"] != "013001" || decoded["seoj_name"] != "Home air conditioner" || decoded["esv_name"] != "GetRes" {
+		t.Errorf("MarshalJSON failure: %s", data)
+	}
+	expect := []any{
+		map[string]any{"epc": "80", "name": "Operation status", "edt": "30", "value": "on"},
+		map[string]any{"epc": "bb", "name": "Measured value of room temperature", "edt": "fe", "value": "-2°C"},
+		map[string]any{"epc": "f0", "edt": "0102"},
+	}
+	if !reflect.DeepEqual(decoded["properties"], expect) {
+		t.Errorf("MarshalJSON failure: %s", data)
+	}
+}
+
+func TestTrace(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	startDevice(t, network, device1Addr, 0x30)
+
+	buf := &bytes.Buffer{}
+	c := echonetlite.NewController()
+	c.Transport = network.Listen(controllerAddr)
+	c.Logger = slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c.Trace = true
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start failure: %v", err)
+	}
+	if _, err := c.QueryBuilder().SetTimeout(time.Second).SetAddress(device1Addr).Query(getFrame(&c, 0x013001, 0x80)); err != nil {
+		t.Fatalf("Query failure: %v", err)
+	}
+	c.Stop()
+
+	log := buf.String()
+	if !strings.Contains(log, `msg="[trace] sent" addr=192.0.2.11:3610 frame.tid=0x0001`) {
+		t.Errorf("trace failure: %s", log)
+	}
+	if !strings.Contains(log, `msg="[trace] received"`) || !strings.Contains(log, `frame.properties="[0x80 Operation status=0x30 (on)]"`) {
+		t.Errorf("trace failure: %s", log)
+	}
+}
@@ -1,9 +1,8 @@
 package echonetlite
 
 import (
-	"bytes"
-	"encoding/binary"
 	"errors"
+	"fmt"
 )
 
 var (
@@ -11,8 +10,35 @@ var (
 	ErrElementsMismatch  = errors.New("the number of elements is mismatched")
 	ErrTooManyProperties = errors.New("too many properties")
 	ErrWrongLength       = errors.New("wrong length")
+	ErrTruncated         = errors.New("truncated data")
 )
 
+// DecodeError is returned by the deserializers when data is malformed.
+// Offset is relative to the data passed to the deserializer.
+type DecodeError struct {
+	Field  string
+	Offset int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s at offset %d: %v", e.Field, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// withOffset shifts the offset of a DecodeError returned from a nested
+// deserializer.
+func withOffset(err error, offset int) error {
+	var decodeError *DecodeError
+	if errors.As(err, &decodeError) {
+		return &DecodeError{Field: decodeError.Field, Offset: decodeError.Offset + offset, Err: decodeError.Err}
+	}
+	return err
+}
+
 type ServiceType byte
 
 const (
@@ -66,6 +92,12 @@ func (t ServiceType) String() string {
 	return "Unknown"
 }
 
+// isSetGet reports whether frames of the service type carry both of the
+// OPCSet and OPCGet property blocks.
+func (t ServiceType) isSetGet() bool {
+	return t == ServiceTypeSetGet || t == ServiceTypeSetGetRes || t == ServiceTypeSetGetSna
+}
+
 type Serializable interface {
 	Serialize() []byte
 }
@@ -88,19 +120,26 @@ func (f Frame) Serialize() ([]byte, error) {
 	return data, nil
 }
 
+// DeserializeFrame decodes an ECHONET Lite frame. Errors are *DecodeError
+// wrapping ErrTruncated, ErrUnsupportedFrame and the like, so check them with
+// errors.Is rather than comparing them directly.
 func DeserializeFrame(data []byte) (Frame, error) {
+	if len(data) < 4 {
+		return Frame{}, &DecodeError{Field: "header", Offset: 0, Err: ErrTruncated}
+	}
+
 	f := Frame{}
 	f.Ehd1 = data[0]
 	f.Ehd2 = data[1]
 	f.Tid = (uint16(data[2]) << 8) | uint16(data[3])
 
 	if f.Ehd1 != 0x10 || f.Ehd2 != 0x81 {
-		return Frame{}, ErrUnsupportedFrame
+		return Frame{}, &DecodeError{Field: "EHD", Offset: 0, Err: ErrUnsupportedFrame}
 	}
 
 	edata, err := DeserializeSpecifiedMessage(data[4:])
 	if err != nil {
-		return Frame{}, err
+		return Frame{}, withOffset(err, 4)
 	}
 	f.Edata = edata
 
@@ -108,73 +147,130 @@ func DeserializeFrame(data []byte) (Frame, error) {
 }
 
 type SpecifiedMessage struct {
-	Seoj       uint32
-	Deoj       uint32
+	Seoj       EOJ
+	Deoj       EOJ
 	Esv        ServiceType
 	Properties []Property
+	// GetProperties is the OPCGet block of SetGet, SetGetRes and SetGetSna
+	// frames. Properties holds the OPCSet block of these frames.
+	GetProperties []Property
 }
 
 func DeserializeSpecifiedMessage(data []byte) (SpecifiedMessage, error) {
-	m := SpecifiedMessage{}
-
-	if err := binary.Read(bytes.NewReader(append([]byte{0}, data[0:3]...)), binary.BigEndian, &m.Seoj); err != nil {
-		return SpecifiedMessage{}, err
+	if len(data) < 8 {
+		return SpecifiedMessage{}, &DecodeError{Field: "EDATA", Offset: 0, Err: ErrTruncated}
 	}
 
-	if err := binary.Read(bytes.NewReader(append([]byte{0}, data[3:6]...)), binary.BigEndian, &m.Deoj); err != nil {
-		return SpecifiedMessage{}, err
+	m := SpecifiedMessage{}
+	m.Seoj = decodeEOJ(data[0:3])
+	m.Deoj = decodeEOJ(data[3:6])
+	m.Esv = ServiceType(data[6])
+	if m.Esv.isSetGet() {
+		setGetMessage, err := deserializeSetGetMessage(m, data[7:])
+		return setGetMessage, withOffset(err, 7)
 	}
 
-	m.Esv = ServiceType(data[6])
 	opc := int(data[7])
 	properties, err := DeserializeProperties(data[8:])
 	if err != nil {
-		return SpecifiedMessage{}, err
+		return SpecifiedMessage{}, withOffset(err, 8)
 	}
 	if opc != len(properties) {
-		return SpecifiedMessage{}, ErrElementsMismatch
+		return SpecifiedMessage{}, &DecodeError{Field: "OPC", Offset: 7, Err: ErrElementsMismatch}
 	}
 
 	m.Properties = properties
 	return m, nil
 }
 
+func deserializeSetGetMessage(m SpecifiedMessage, data []byte) (SpecifiedMessage, error) {
+	setProperties, setLength, err := deserializePropertyBlock(data)
+	if err != nil {
+		return SpecifiedMessage{}, err
+	}
+	getProperties, getLength, err := deserializePropertyBlock(data[setLength:])
+	if err != nil {
+		return SpecifiedMessage{}, withOffset(err, setLength)
+	}
+	if setLength+getLength != len(data) {
+		return SpecifiedMessage{}, &DecodeError{Field: "OPCGet", Offset: setLength, Err: ErrElementsMismatch}
+	}
+
+	m.Properties = setProperties
+	m.GetProperties = getProperties
+	return m, nil
+}
+
+// deserializePropertyBlock reads an OPC followed by OPC properties and
+// returns the properties and the number of bytes consumed.
+func deserializePropertyBlock(data []byte) ([]Property, int, error) {
+	if len(data) < 1 {
+		return []Property{}, 0, &DecodeError{Field: "OPC", Offset: 0, Err: ErrTruncated}
+	}
+
+	p := []Property{}
+
+	opc := int(data[0])
+	offset := 1
+	for i := 0; i < opc; i++ {
+		prop, n, err := deserializeNextProperty(data, offset)
+		if err != nil {
+			return []Property{}, 0, err
+		}
+		p = append(p, prop)
+		offset += n
+	}
+
+	return p, offset, nil
+}
+
+// deserializeNextProperty reads a property at offset and returns the
+// property and the number of bytes consumed.
+func deserializeNextProperty(data []byte, offset int) (Property, int, error) {
+	if offset+2 > len(data) {
+		return Property{}, 0, &DecodeError{Field: "property", Offset: offset, Err: ErrTruncated}
+	}
+	pdc := int(data[offset+1])
+	if offset+pdc+2 > len(data) {
+		return Property{}, 0, &DecodeError{Field: "EDT", Offset: offset + 2, Err: ErrTruncated}
+	}
+	prop, err := DeserializeProperty(data[offset : offset+pdc+2])
+	if err != nil {
+		return Property{}, 0, withOffset(err, offset)
+	}
+	return prop, pdc + 2, nil
+}
+
 func DeserializeProperties(data []byte) ([]Property, error) {
 	p := []Property{}
 
 	offset := 0
 	for offset < len(data) {
-		pdc := int(data[offset+1])
-		prop, err := DeserializeProperty(data[offset : offset+pdc+2])
+		prop, n, err := deserializeNextProperty(data, offset)
 		if err != nil {
 			return []Property{}, err
 		}
 		p = append(p, prop)
-		offset += pdc + 2
+		offset += n
 	}
 
 	return p, nil
 }
 
 func (m SpecifiedMessage) Serialize() ([]byte, error) {
-	if len(m.Properties) > 255 {
+	if len(m.Properties) > 255 || len(m.GetProperties) > 255 {
 		return nil, ErrTooManyProperties
 	}
 
-	data := []byte{
-		// Seoj
-		byte((m.Seoj >> 16) & 0xff),
-		byte((m.Seoj >> 8) & 0xff),
-		byte(m.Seoj & 0xff),
-		// Deoj
-		byte((m.Deoj >> 16) & 0xff),
-		byte((m.Deoj >> 8) & 0xff),
-		byte(m.Deoj & 0xff),
+	data := []byte{}
+	data = append(data, m.Seoj.bytes()...)
+	data = append(data, m.Deoj.bytes()...)
+	data = append(data,
 		// Esv
 		byte(m.Esv),
 		// Opc
 		byte(len(m.Properties)),
-	}
+	)
 	for _, property := range m.Properties {
 		propertyBytes, err := property.Serialize()
 		if err != nil {
@@ -182,6 +278,17 @@ func (m SpecifiedMessage) Serialize() ([]byte, error) {
 		}
 		data = append(data, propertyBytes...)
 	}
+
+	if m.Esv.isSetGet() {
+		data = append(data, byte(len(m.GetProperties)))
+		for _, property := range m.GetProperties {
+			propertyBytes, err := property.Serialize()
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, propertyBytes...)
+		}
+	}
 	return data, nil
 }
 
@@ -191,12 +298,16 @@ type Property struct {
 }
 
 func DeserializeProperty(data []byte) (Property, error) {
+	if len(data) < 2 {
+		return Property{}, &DecodeError{Field: "property", Offset: 0, Err: ErrTruncated}
+	}
+
 	p := Property{}
 
 	p.Epc = data[0]
 	pdc := int(data[1])
 	if pdc+2 != len(data) {
-		return Property{}, ErrWrongLength
+		return Property{}, &DecodeError{Field: "PDC", Offset: 1, Err: ErrWrongLength}
 	}
 	p.Edt = make([]byte, pdc)
 	copy(p.Edt, data[2:2+pdc])
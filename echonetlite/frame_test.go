@@ -1,6 +1,7 @@
 package echonetlite_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -120,6 +121,67 @@ func TestDeserializeSpecifiedMessage(t *testing.T) {
 	}
 }
 
+func TestSetGetSpecifiedMessage(t *testing.T) {
+	message := echonetlite.SpecifiedMessage{
+		Seoj: 0x05ff01,
+		Deoj: 0x013001,
+		Esv:  echonetlite.ServiceTypeSetGet,
+		Properties: []echonetlite.Property{
+			{
+				Epc: 0xb3,
+				Edt: []byte{0x1a},
+			},
+		},
+		GetProperties: []echonetlite.Property{
+			{
+				Epc: 0xbb,
+				Edt: []byte{},
+			},
+			{
+				Epc: 0xba,
+				Edt: []byte{},
+			},
+		},
+	}
+	data := []byte{
+		0x05, 0xff, 0x01,
+		0x01, 0x30, 0x01,
+		0x6e,
+		0x01,
+		0xb3, 0x01, 0x1a,
+		0x02,
+		0xbb, 0x00,
+		0xba, 0x00,
+	}
+
+	t.Run("Serialize", func(t *testing.T) {
+		actual, err := message.Serialize()
+		if err != nil {
+			t.Errorf("Serialize failure: %v", err)
+		}
+		if !reflect.DeepEqual(actual, data) {
+			t.Errorf("Serialize failure")
+		}
+	})
+
+	t.Run("Deserialize", func(t *testing.T) {
+		actual, err := echonetlite.DeserializeSpecifiedMessage(data)
+		if err != nil {
+			t.Errorf("DeserializeSpecifiedMessage failure: %v", err)
+		}
+		if !reflect.DeepEqual(actual, message) {
+			t.Errorf("DeserializeSpecifiedMessage failure")
+		}
+	})
+
+	t.Run("DeserializeTrailingData", func(t *testing.T) {
+		_, err := echonetlite.DeserializeSpecifiedMessage(append(data, 0x00))
+		if !errors.Is(err, echonetlite.ErrElementsMismatch) {
+			t.Errorf("DeserializeSpecifiedMessage failure: %v", err)
+		}
+	})
+}
+
 func TestDeserializeProperties(t *testing.T) {
 	actual, err := echonetlite.DeserializeProperties([]byte{
 		0x01,
@@ -181,3 +243,33 @@ func TestDeserializeProperty(t *testing.T) {
 		t.Errorf("DeserializeProperty failure")
 	}
 }
+
+func TestDeserializeFrameMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		err    error
+		offset int
+	}{
+		{"Empty", []byte{}, echonetlite.ErrTruncated, 0},
+		{"TruncatedHeader", []byte{0x10, 0x81, 0x00}, echonetlite.ErrTruncated, 0},
+		{"UnsupportedHeader", []byte{0x10, 0x82, 0x00, 0x01, 0x05, 0xff, 0x01, 0x01, 0x30, 0x01, 0x62, 0x00}, echonetlite.ErrUnsupportedFrame, 0},
+		{"TruncatedEdata", []byte{0x10, 0x81, 0x00, 0x01, 0x05, 0xff, 0x01, 0x01, 0x30}, echonetlite.ErrTruncated, 4},
+		{"TruncatedProperty", []byte{0x10, 0x81, 0x00, 0x01, 0x05, 0xff, 0x01, 0x01, 0x30, 0x01, 0x62, 0x01, 0x80}, echonetlite.ErrTruncated, 12},
+		{"TruncatedEdt", []byte{0x10, 0x81, 0x00, 0x01, 0x01, 0x30, 0x01, 0x05, 0xff, 0x01, 0x72, 0x01, 0x80, 0x02, 0x30}, echonetlite.ErrTruncated, 14},
+		{"OpcMismatch", []byte{0x10, 0x81, 0x00, 0x01, 0x01, 0x30, 0x01, 0x05, 0xff, 0x01, 0x72, 0x02, 0x80, 0x01, 0x30}, echonetlite.ErrElementsMismatch, 11},
+		{"TruncatedSetGet", []byte{0x10, 0x81, 0x00, 0x01, 0x05, 0xff, 0x01, 0x01, 0x30, 0x01, 0x6e, 0x01, 0xb3, 0x01, 0x1a}, echonetlite.ErrTruncated, 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := echonetlite.DeserializeFrame(tt.data)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("DeserializeFrame failure: %v", err)
+			}
+			var decodeError *echonetlite.DecodeError
+			if !errors.As(err, &decodeError) || decodeError.Offset != tt.offset {
+				t.Errorf("DeserializeFrame failure: %v", err)
+			}
+		})
+	}
+}
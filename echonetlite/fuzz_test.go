@@ -0,0 +1,76 @@
+package echonetlite_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+func FuzzDeserializeFrame(f *testing.F) {
+	f.Add([]byte{0x10, 0x81, 0x12, 0x34, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0x60, 0x00})
+	f.Add([]byte{0x10, 0x81, 0x00, 0x01, 0x01, 0x30, 0x01, 0x05, 0xff, 0x01, 0x72, 0x02, 0x80, 0x01, 0x30, 0xb3, 0x01, 0x1a})
+	f.Add([]byte{0x10, 0x81, 0x00, 0x01, 0x05, 0xff, 0x01, 0x01, 0x30, 0x01, 0x6e, 0x01, 0xb3, 0x01, 0x1a, 0x01, 0xbb, 0x00})
+	f.Add([]byte{0x10, 0x81, 0x00, 0x01, 0x01, 0x30, 0x01, 0x05, 0xff, 0x01, 0x72, 0x01, 0x80, 0x05})
+	f.Add([]byte{0x10, 0x81})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		frame, err := echonetlite.DeserializeFrame(data)
+		if err != nil {
+			return
+		}
+
+		serialized, err := frame.Serialize()
+		if err != nil {
+			t.Fatalf("failed to serialize a deserialized frame: %v", err)
+		}
+		if !bytes.Equal(serialized, data) {
+			t.Fatalf("round trip mismatch: %x != %x", serialized, data)
+		}
+	})
+}
+
+func FuzzDeserializeProperties(f *testing.F) {
+	f.Add([]byte{0x01, 0x02, 0x02, 0x03, 0x04, 0x03, 0x05, 0x06, 0x07})
+	f.Add([]byte{0x01, 0xff})
+	f.Add([]byte{0x01})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		properties, err := echonetlite.DeserializeProperties(data)
+		if err != nil {
+			return
+		}
+
+		serialized := []byte{}
+		for _, p := range properties {
+			b, err := p.Serialize()
+			if err != nil {
+				t.Fatalf("failed to serialize a deserialized property: %v", err)
+			}
+			serialized = append(serialized, b...)
+		}
+		if !bytes.Equal(serialized, data) {
+			t.Fatalf("round trip mismatch: %x != %x", serialized, data)
+		}
+	})
+}
+
+func FuzzGetPropertyMap(f *testing.F) {
+	f.Add([]byte{0x03, 0x80, 0x81, 0x9f})
+	bitmap, _ := echonetlite.EncodePropertyMap([]byte{
+		0x80, 0x81, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+		0x88, 0x89, 0x8a, 0x8b, 0x8c, 0x8d, 0x8e, 0x8f,
+	})
+	f.Add(bitmap)
+	f.Add([]byte{0x20})
+
+	f.Fuzz(func(t *testing.T, edt []byte) {
+		epcs, err := echonetlite.GetPropertyMap(echonetlite.Property{Epc: 0x9f, Edt: edt})
+		if err != nil {
+			return
+		}
+		if len(epcs) != int(edt[0]) {
+			t.Fatalf("the number of properties is mismatched: %d != %d", len(epcs), edt[0])
+		}
+	})
+}
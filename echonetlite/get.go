@@ -0,0 +1,311 @@
+package echonetlite
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrNoResponsesForEpc = errors.New("no responses for epc")
+	// ErrPropertyNotAvailable is returned for a property which the device
+	// failed to read in a Get_SNA response.
+	ErrPropertyNotAvailable = errors.New("property not available")
+	// ErrPropertyNotSupported is returned for a property which was not
+	// requested since it is not in the Get property map of the device.
+	ErrPropertyNotSupported = errors.New("property not supported")
+	// ErrUnexpectedValue is returned for an EDT of a wrong length or a
+	// value which the specification doesn't define.
+	ErrUnexpectedValue = errors.New("unexpected value")
+	// ErrUnsupportedValue is returned for a value which is defined but
+	// can't be represented, or is out of the range to set.
+	ErrUnsupportedValue = errors.New("unsupported value")
+)
+
+// PropertyRequest is a request of properties of a device object, which
+// device packages like daikin wrap in their QueryRequest. It is immutable, so
+// a request can be extended without affecting others.
+type PropertyRequest struct {
+	controller *Controller
+	object     EOJ
+	epcs       []byte
+	address    *net.UDPAddr
+	retry      RetryPolicy
+	timeout    time.Duration
+}
+
+func NewPropertyRequest(c *Controller, object EOJ) PropertyRequest {
+	return PropertyRequest{
+		controller: c,
+		object:     object,
+		epcs:       []byte{},
+	}
+}
+
+// Get queries the properties with GetProperties.
+func (r PropertyRequest) Get(ctx context.Context) ([]PropertyResponse, error) {
+	builder := r.controller.QueryBuilder().SetTimeout(r.timeout).SetRetry(r.retry)
+	if r.address != nil {
+		builder.SetAddress(*r.address)
+	}
+	return builder.GetProperties(ctx, r.object, r.epcs)
+}
+
+// WithEpc adds epc to the request.
+func (r PropertyRequest) WithEpc(epc byte) PropertyRequest {
+	if i, found := slices.BinarySearch(r.epcs, epc); !found {
+		r.epcs = slices.Insert(slices.Clone(r.epcs), i, epc)
+	}
+	return r
+}
+
+// WithInstance sends the request to the object of the same class with the
+// instance code. An instance code of 0 addresses all instances in a device.
+func (r PropertyRequest) WithInstance(instanceCode byte) PropertyRequest {
+	r.object = r.object.WithInstance(instanceCode)
+	return r
+}
+
+// WithAddress sends the request only to the device at addr.
+func (r PropertyRequest) WithAddress(addr net.UDPAddr) PropertyRequest {
+	r.address = &addr
+	return r
+}
+
+// WithRetry retries the request according to policy if responses are lost.
+func (r PropertyRequest) WithRetry(policy RetryPolicy) PropertyRequest {
+	r.retry = policy
+	return r
+}
+
+// WithTimeout sets the timeout of each attempt. See QueryBuilder.Timeout.
+func (r PropertyRequest) WithTimeout(timeout time.Duration) PropertyRequest {
+	r.timeout = timeout
+	return r
+}
+
+// PropertyResponse is the properties of a device object in a Get_Res or
+// Get_SNA response to GetProperties.
+type PropertyResponse struct {
+	Address net.UDPAddr
+	// Object is the device object which responded.
+	Object EOJ
+	// Partial is true if the device responded with Get_SNA, so some of the
+	// requested properties have no value.
+	Partial bool
+	data    map[byte][]byte
+	errs    map[byte]error
+}
+
+// newPropertyResponse converts a Get_Res or Get_SNA response to requested
+// EPCs.
+func newPropertyResponse(res QueryResponse, requested []byte) PropertyResponse {
+	p := PropertyResponse{
+		Address: res.Addr,
+		Object:  res.Frame.Edata.Seoj,
+		Partial: res.Frame.Edata.Esv == ServiceTypeGetSna,
+		data:    map[byte][]byte{},
+		errs:    map[byte]error{},
+	}
+	for _, prop := range res.Frame.Edata.Properties {
+		if p.Partial && len(prop.Edt) == 0 {
+			p.errs[prop.Epc] = ErrPropertyNotAvailable
+			continue
+		}
+		p.data[prop.Epc] = prop.Edt
+	}
+	for _, epc := range requested {
+		_, hasData := p.data[epc]
+		_, hasErr := p.errs[epc]
+		if !hasData && !hasErr {
+			// skipped by GetProperties
+			p.errs[epc] = ErrPropertyNotSupported
+		}
+	}
+	return p
+}
+
+// Edt returns the EDT of epc, or why the device did not respond with a
+// value for it.
+func (p PropertyResponse) Edt(epc byte) ([]byte, error) {
+	if data, ok := p.data[epc]; ok {
+		return data, nil
+	}
+	return nil, p.Err(epc)
+}
+
+// FixedEdt returns the EDT of epc like Edt, or ErrUnexpectedValue if it is
+// not size bytes long.
+func (p PropertyResponse) FixedEdt(epc byte, size int) ([]byte, error) {
+	data, err := p.Edt(epc)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != size {
+		return nil, ErrUnexpectedValue
+	}
+	return data, nil
+}
+
+// IdentificationNumber returns the identification number (0x83) without the
+// lower communication ID, which is 0xfe for a unique number.
+func (p PropertyResponse) IdentificationNumber() ([]byte, error) {
+	data, err := p.FixedEdt(EpcIdentificationNumber, 17)
+	if err != nil {
+		return nil, err
+	}
+
+	if data[0] != 0xfe {
+		return nil, ErrUnsupportedValue
+	}
+	return slices.Clone(data[1:]), nil
+}
+
+// Err returns why the device did not respond with a value for epc, or nil if
+// it did.
+func (p PropertyResponse) Err(epc byte) error {
+	if _, ok := p.data[epc]; ok {
+		return nil
+	}
+	if err, ok := p.errs[epc]; ok {
+		return err
+	}
+	return ErrNoResponsesForEpc
+}
+
+// Errors returns errors for the requested properties which have no value.
+func (p PropertyResponse) Errors() map[byte]error {
+	return maps.Clone(p.errs)
+}
+
+// GetProperties queries epcs of eoj until ctx is done or the timeout
+// expires. A query to an address specified by SetAddress returns as soon as
+// the device responds, or all instances respond if the instance code of eoj
+// is 0.
+//
+// EPCs which are not in the Get property map of a device are not requested,
+// since some devices reject the whole request with Get_SNA if it contains an
+// unsupported EPC. The property maps are queried and cached on the first
+// request to a device, or after a device responded with Get_SNA to a
+// multicast request or a request to all instances. Up to four of such
+// devices are queried again in a call.
+//
+// A device which responds with Get_SNA is returned as a partial
+// PropertyResponse. See PropertyResponse.Err for properties without a value.
+func (q QueryBuilder) GetProperties(ctx context.Context, eoj EOJ, epcs []byte) ([]PropertyResponse, error) {
+	requested := epcs
+	allInstances := eoj.InstanceCode() == 0
+	if q.Address != nil && !allInstances {
+		if caps, err := q.Capabilities(ctx, eoj); err == nil {
+			epcs = caps.FilterGet(epcs)
+		}
+	}
+
+	opts := QueryOptions{}
+	if q.Address != nil && allInstances {
+		// wait for all instances instead of the first response from the address
+		opts.Count = q.countInstances(ctx, eoj)
+	}
+	responses, err := q.QueryContext(ctx, q.getFrame(eoj, epcs), opts)
+	if err != nil {
+		return []PropertyResponse{}, err
+	}
+	if q.Address == nil || allInstances {
+		q.retryPartial(ctx, responses, epcs)
+	}
+
+	results := []PropertyResponse{}
+	for _, res := range responses {
+		results = append(results, newPropertyResponse(res, requested))
+	}
+	return results, nil
+}
+
+func (q QueryBuilder) getFrame(eoj EOJ, epcs []byte) Frame {
+	f := q.controller.CreateFrame()
+	f.Edata = SpecifiedMessage{
+		Seoj:       ObjectController,
+		Deoj:       eoj,
+		Esv:        ServiceTypeGet,
+		Properties: []Property{},
+	}
+	for _, epc := range epcs {
+		f.Edata.Properties = append(f.Edata.Properties, Property{Epc: epc, Edt: []byte{}})
+	}
+	return f
+}
+
+// countInstances returns the number of instances of the class of eoj in the
+// device at the address specified by SetAddress, or 0 if it is unknown. The
+// number is cached like capabilities if the device responds.
+func (q QueryBuilder) countInstances(ctx context.Context, eoj EOJ) int {
+	key := newCapabilityKey(*q.Address, eoj)
+	if count, ok := q.controller.capabilities.getInstances(key); ok {
+		return count
+	}
+
+	nodes, err := q.Discover(ctx)
+	if err != nil || len(nodes) < 1 {
+		// the device may respond next time
+		return 0
+	}
+	count := len(nodes[0].InstancesOf(eoj.Class()))
+	q.controller.capabilities.setInstances(key, count)
+	return count
+}
+
+// maxPartialRetries is the number of Get_SNA responses to a GetProperties
+// call which are retried with only supported EPCs, bounding the round trips
+// a multicast request causes.
+const maxPartialRetries = 4
+
+// retryPartial replaces up to maxPartialRetries Get_SNA responses in
+// responses with the responses to retrySupported. The objects are queried
+// concurrently.
+func (q QueryBuilder) retryPartial(ctx context.Context, responses []QueryResponse, epcs []byte) {
+	var wg sync.WaitGroup
+	retries := 0
+	for i, res := range responses {
+		if res.Frame.Edata.Esv == ServiceTypeGetRes {
+			continue
+		}
+		if retries == maxPartialRetries {
+			break
+		}
+		retries++
+
+		wg.Add(1)
+		go func(i int, res QueryResponse) {
+			defer wg.Done()
+			if retried, ok := q.retrySupported(ctx, res.Addr, res.Frame.Edata.Seoj, epcs); ok {
+				responses[i] = retried
+			}
+		}(i, res)
+	}
+	wg.Wait()
+}
+
+// retrySupported queries the object eoj at addr again only for EPCs in its
+// Get property map. It returns false if the property maps are not available
+// or the retry would request the same or no EPCs.
+func (q QueryBuilder) retrySupported(ctx context.Context, addr net.UDPAddr, eoj EOJ, epcs []byte) (QueryResponse, bool) {
+	q.SetAddress(addr)
+	caps, err := q.Capabilities(ctx, eoj)
+	if err != nil {
+		return QueryResponse{}, false
+	}
+	supported := caps.FilterGet(epcs)
+	if len(supported) == 0 || len(supported) == len(epcs) {
+		return QueryResponse{}, false
+	}
+	responses, err := q.QueryContext(ctx, q.getFrame(eoj, supported), QueryOptions{})
+	if err != nil || len(responses) < 1 {
+		return QueryResponse{}, false
+	}
+	return responses[0], true
+}
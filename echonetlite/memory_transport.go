@@ -0,0 +1,109 @@
+package echonetlite
+
+import (
+	"net"
+	"sync"
+)
+
+// MemoryNetwork connects MemoryTransports in memory. It is intended for tests.
+type MemoryNetwork struct {
+	m          sync.Mutex
+	transports []*MemoryTransport
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{transports: []*MemoryTransport{}}
+}
+
+// memoryTransportBuffer is the number of datagrams a MemoryTransport holds
+// before it drops them, like the receive buffer of a UDP socket.
+const memoryTransportBuffer = 1024
+
+// Listen creates a transport with addr in the network.
+func (n *MemoryNetwork) Listen(addr net.UDPAddr) *MemoryTransport {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	t := &MemoryTransport{
+		network: n,
+		addr:    addr,
+		packets: make(chan packet, memoryTransportBuffer),
+		closed:  make(chan struct{}),
+	}
+	n.transports = append(n.transports, t)
+	return t
+}
+
+func (n *MemoryNetwork) remove(target *MemoryTransport) {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	newSlice := []*MemoryTransport{}
+	for _, t := range n.transports {
+		if t != target {
+			newSlice = append(newSlice, t)
+		}
+	}
+	n.transports = newSlice
+}
+
+// deliver delivers data to transports matching dst. Like UDP, datagrams are
+// dropped if a receiver queue is full.
+func (n *MemoryNetwork) deliver(src net.UDPAddr, data []byte, dst func(t *MemoryTransport) bool) {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	for _, t := range n.transports {
+		if !dst(t) {
+			continue
+		}
+		select {
+		case t.packets <- packet{data: append([]byte{}, data...), addr: src}:
+		default:
+		}
+	}
+}
+
+type MemoryTransport struct {
+	network *MemoryNetwork
+	addr    net.UDPAddr
+	packets chan packet
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (t *MemoryTransport) Addr() net.UDPAddr {
+	return t.addr
+}
+
+func (t *MemoryTransport) Send(addr net.UDPAddr, data []byte) error {
+	t.network.deliver(t.addr, data, func(other *MemoryTransport) bool {
+		return other.addr.IP.Equal(addr.IP) && other.addr.Port == addr.Port
+	})
+	return nil
+}
+
+// SendMulticast delivers data to all other transports in the network.
+func (t *MemoryTransport) SendMulticast(data []byte) error {
+	t.network.deliver(t.addr, data, func(other *MemoryTransport) bool {
+		return other != t
+	})
+	return nil
+}
+
+func (t *MemoryTransport) Receive() ([]byte, net.UDPAddr, error) {
+	select {
+	case p := <-t.packets:
+		return p.data, p.addr, nil
+	case <-t.closed:
+		return nil, net.UDPAddr{}, net.ErrClosed
+	}
+}
+
+func (t *MemoryTransport) Close() error {
+	t.once.Do(func() {
+		t.network.remove(t)
+		close(t.closed)
+	})
+	return nil
+}
@@ -0,0 +1,623 @@
+package mra
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DataType is a definition of an EDT in the MRA. Which fields are used
+// depends on Type: "number", "state", "numericValue", "level", "bitmap",
+// "date", "date-time", "time", "raw", "array" or "object". OneOf is used
+// instead of Type for an EDT which is one of the data types, e.g. a
+// temperature or a state for an undefined value.
+type DataType struct {
+	Ref  string `json:"$ref"`
+	Type string `json:"type"`
+	// Format is the integer type of a number, e.g. "uint8" or "int16".
+	Format string `json:"format"`
+	// Size is the number of bytes of a state, numericValue, bitmap,
+	// date-time or time.
+	Size int `json:"size"`
+	// Minimum and Maximum are the range of the EDT of a number, which is
+	// before being scaled by MultipleOf, or the maximum of a level.
+	Minimum    *float64 `json:"minimum"`
+	Maximum    *float64 `json:"maximum"`
+	MultipleOf float64  `json:"multipleOf"`
+	Unit       string   `json:"unit"`
+	// Coefficient is the EPCs of properties, e.g. "0xD3", whose values the
+	// number needs to be multiplied by.
+	Coefficient   []string `json:"coefficient"`
+	OverflowCode  bool     `json:"overflowCode"`
+	UnderflowCode bool     `json:"underflowCode"`
+	// Enum is the values of a state or a numericValue.
+	Enum []EnumValue `json:"enum"`
+	// Base is the EDT of the level 1, e.g. "0x31".
+	Base       string          `json:"base"`
+	Bitmaps    []BitmapField   `json:"bitmaps"`
+	ItemSize   int             `json:"itemSize"`
+	MinItems   int             `json:"minItems"`
+	MaxItems   int             `json:"maxItems"`
+	Items      *DataType       `json:"items"`
+	Properties []ObjectElement `json:"properties"`
+	MinSize    int             `json:"minSize"`
+	MaxSize    int             `json:"maxSize"`
+	OneOf      []*DataType     `json:"oneOf"`
+
+	base byte
+}
+
+type EnumValue struct {
+	Edt          string  `json:"edt"`
+	Name         string  `json:"name"`
+	Descriptions Text    `json:"descriptions"`
+	NumericValue float64 `json:"numericValue"`
+
+	edt uint64
+}
+
+type BitmapField struct {
+	Name         string `json:"name"`
+	Descriptions Text   `json:"descriptions"`
+	Position     struct {
+		Index   int    `json:"index"`
+		BitMask string `json:"bitMask"`
+	} `json:"position"`
+	Value *DataType `json:"value"`
+
+	mask byte
+}
+
+type ObjectElement struct {
+	ElementName string    `json:"elementName"`
+	Element     *DataType `json:"element"`
+}
+
+var numberSizes = map[string]int{
+	"int8":   1,
+	"int16":  2,
+	"int32":  4,
+	"uint8":  1,
+	"uint16": 2,
+	"uint32": 4,
+}
+
+// resolver replaces references to definitions with the definitions and
+// parses the codes in them.
+type resolver struct {
+	definitions map[string]*DataType
+	resolved    map[string]*DataType
+}
+
+func (r *resolver) resolve(t *DataType) (*DataType, error) {
+	if t == nil {
+		return nil, fmt.Errorf("%w: no data type", ErrUnknownType)
+	}
+	if t.Ref != "" {
+		name := strings.TrimPrefix(t.Ref, "#/definitions/")
+		if resolved, ok := r.resolved[name]; ok {
+			return resolved, nil
+		}
+		def, ok := r.definitions[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownType, t.Ref)
+		}
+		resolved, err := r.resolve(def)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		r.resolved[name] = resolved
+		return resolved, nil
+	}
+
+	var err error
+	if len(t.OneOf) > 0 {
+		for i := range t.OneOf {
+			if t.OneOf[i], err = r.resolve(t.OneOf[i]); err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+
+	switch t.Type {
+	case "number":
+		if _, ok := numberSizes[t.Format]; !ok {
+			return nil, fmt.Errorf("%w: number format %q", ErrUnknownType, t.Format)
+		}
+	case "state", "numericValue":
+		for i := range t.Enum {
+			if t.Enum[i].edt, err = parseHex(t.Enum[i].Edt, 64); err != nil {
+				return nil, fmt.Errorf("edt: %w", err)
+			}
+		}
+	case "level":
+		base, err := parseHex(t.Base, 8)
+		if err != nil {
+			return nil, fmt.Errorf("base: %w", err)
+		}
+		t.base = byte(base)
+		if t.Maximum == nil {
+			return nil, fmt.Errorf("%w: level without maximum", ErrUnknownType)
+		}
+	case "bitmap":
+		for i := range t.Bitmaps {
+			field := &t.Bitmaps[i]
+			mask, err := parseBitMask(field.Position.BitMask)
+			if err != nil {
+				return nil, fmt.Errorf("bitMask: %w", err)
+			}
+			field.mask = mask
+			if field.Position.Index >= t.size() {
+				return nil, fmt.Errorf("%w: bitmap index %d", ErrUnknownType, field.Position.Index)
+			}
+			if field.Value, err = r.resolve(field.Value); err != nil {
+				return nil, err
+			}
+		}
+	case "array":
+		if t.ItemSize <= 0 {
+			return nil, fmt.Errorf("%w: array without itemSize", ErrUnknownType)
+		}
+		if t.Items, err = r.resolve(t.Items); err != nil {
+			return nil, err
+		}
+	case "object":
+		for i := range t.Properties {
+			if t.Properties[i].Element, err = r.resolve(t.Properties[i].Element); err != nil {
+				return nil, fmt.Errorf("%s: %w", t.Properties[i].ElementName, err)
+			}
+		}
+	case "date", "date-time", "time", "raw":
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, t.Type)
+	}
+	return t, nil
+}
+
+func parseBitMask(s string) (byte, error) {
+	if strings.HasPrefix(s, "0b") {
+		mask, err := strconv.ParseUint(s[2:], 2, 8)
+		return byte(mask), err
+	}
+	mask, err := parseHex(s, 8)
+	return byte(mask), err
+}
+
+// size returns the number of bytes of a state, numericValue, bitmap,
+// date-time or time.
+func (t *DataType) size() int {
+	if t.Size > 0 {
+		return t.Size
+	}
+	switch t.Type {
+	case "date-time":
+		return 7
+	case "time":
+		return 2
+	default:
+		return 1
+	}
+}
+
+// FixedSize returns the number of bytes of the EDT, or -1 if it varies.
+func (t *DataType) FixedSize() int {
+	if len(t.OneOf) > 0 {
+		size := t.OneOf[0].FixedSize()
+		for _, alt := range t.OneOf[1:] {
+			if alt.FixedSize() != size {
+				return -1
+			}
+		}
+		return size
+	}
+
+	switch t.Type {
+	case "number":
+		return numberSizes[t.Format]
+	case "level":
+		return 1
+	case "date":
+		return 4
+	case "raw":
+		if t.MinSize > 0 && t.MinSize == t.MaxSize {
+			return t.MinSize
+		}
+		return -1
+	case "array":
+		if t.MinItems == t.MaxItems {
+			return t.ItemSize * t.MaxItems
+		}
+		return -1
+	case "object":
+		size := 0
+		for _, element := range t.Properties {
+			elementSize := element.Element.FixedSize()
+			if elementSize < 0 {
+				return -1
+			}
+			size += elementSize
+		}
+		return size
+	default:
+		return t.size()
+	}
+}
+
+func invalidEdt(t *DataType, edt []byte) error {
+	return fmt.Errorf("%w: %s 0x%x", ErrInvalidEdt, t.Type, edt)
+}
+
+func invalidValue(t *DataType, v Value) error {
+	return fmt.Errorf("%w: %s %v", ErrInvalidValue, t.Type, v)
+}
+
+func readUint(edt []byte) uint64 {
+	value := uint64(0)
+	for _, b := range edt {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func putUint(size int, value uint64) []byte {
+	edt := make([]byte, size)
+	for i := size - 1; i >= 0; i-- {
+		edt[i] = byte(value)
+		value >>= 8
+	}
+	return edt
+}
+
+// scale returns the factors to multiply and divide EDTs of a number by. A
+// multipleOf below 1 is a divisor so that e.g. 265 * 0.1 is 26.5 rather than
+// 26.500000000000004.
+func (t *DataType) scale() (multiply float64, divide float64) {
+	if t.MultipleOf == 0 || t.MultipleOf == 1 {
+		return 1, 1
+	}
+	if t.MultipleOf < 1 {
+		return 1, math.Round(1 / t.MultipleOf)
+	}
+	return t.MultipleOf, 1
+}
+
+// Decode decodes edt into a Value.
+func (t *DataType) Decode(edt []byte) (Value, error) {
+	if len(t.OneOf) > 0 {
+		for _, alt := range t.OneOf {
+			if v, err := alt.Decode(edt); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: no data type matches 0x%x", ErrInvalidEdt, edt)
+	}
+
+	if size := t.FixedSize(); size >= 0 && len(edt) != size {
+		return nil, invalidEdt(t, edt)
+	}
+
+	switch t.Type {
+	case "number":
+		return t.decodeNumber(edt)
+	case "state", "numericValue":
+		code := readUint(edt)
+		for _, e := range t.Enum {
+			if e.edt != code {
+				continue
+			}
+			if t.Type == "numericValue" {
+				return Number{Value: e.NumericValue, Unit: t.Unit}, nil
+			}
+			return State{Edt: code, Name: e.Name, Description: e.Descriptions.En}, nil
+		}
+		return nil, invalidEdt(t, edt)
+	case "level":
+		if edt[0] < t.base || int(edt[0]-t.base) >= int(*t.Maximum) {
+			return nil, invalidEdt(t, edt)
+		}
+		return Level(edt[0] - t.base + 1), nil
+	case "bitmap":
+		b := Bitmap{}
+		for _, field := range t.Bitmaps {
+			code := edt[field.Position.Index] & field.mask >> bits.TrailingZeros8(field.mask)
+			v, err := field.Value.Decode([]byte{code})
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+			b[field.Name] = v
+		}
+		return b, nil
+	case "date":
+		date := time.Date(int(binary.BigEndian.Uint16(edt)), time.Month(edt[2]), int(edt[3]), 0, 0, 0, 0, time.UTC)
+		if date.Month() != time.Month(edt[2]) || date.Day() != int(edt[3]) {
+			return nil, invalidEdt(t, edt)
+		}
+		return Date{date}, nil
+	case "date-time":
+		if len(edt) < 6 {
+			return nil, invalidEdt(t, edt)
+		}
+		second := 0
+		if len(edt) > 6 {
+			second = int(edt[6])
+		}
+		if edt[4] > 23 || edt[5] > 59 || second > 59 {
+			return nil, invalidEdt(t, edt)
+		}
+		dt := time.Date(int(binary.BigEndian.Uint16(edt)), time.Month(edt[2]), int(edt[3]), int(edt[4]), int(edt[5]), second, 0, time.UTC)
+		if dt.Month() != time.Month(edt[2]) || dt.Day() != int(edt[3]) {
+			return nil, invalidEdt(t, edt)
+		}
+		return DateTime{dt}, nil
+	case "time":
+		if len(edt) < 2 || len(edt) > 3 {
+			return nil, invalidEdt(t, edt)
+		}
+		v := Time{Hour: int(edt[0]), Minute: int(edt[1])}
+		if len(edt) > 2 {
+			v.Second = int(edt[2])
+		}
+		if v.Minute > 59 || v.Second > 59 {
+			return nil, invalidEdt(t, edt)
+		}
+		return v, nil
+	case "raw":
+		if len(edt) < t.MinSize || (t.MaxSize > 0 && len(edt) > t.MaxSize) {
+			return nil, invalidEdt(t, edt)
+		}
+		return Raw(append([]byte{}, edt...)), nil
+	case "array":
+		if len(edt)%t.ItemSize != 0 {
+			return nil, invalidEdt(t, edt)
+		}
+		count := len(edt) / t.ItemSize
+		if count < t.MinItems || (t.MaxItems > 0 && count > t.MaxItems) {
+			return nil, invalidEdt(t, edt)
+		}
+		a := Array{}
+		for i := 0; i < count; i++ {
+			v, err := t.Items.Decode(edt[i*t.ItemSize : (i+1)*t.ItemSize])
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case "object":
+		o := Object{}
+		rest := edt
+		for i, element := range t.Properties {
+			size := element.Element.FixedSize()
+			if size < 0 && i == len(t.Properties)-1 {
+				size = len(rest)
+			}
+			if size < 0 || size > len(rest) {
+				return nil, invalidEdt(t, edt)
+			}
+			v, err := element.Element.Decode(rest[:size])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", element.ElementName, err)
+			}
+			o[element.ElementName] = v
+			rest = rest[size:]
+		}
+		if len(rest) != 0 {
+			return nil, invalidEdt(t, edt)
+		}
+		return o, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownType, t.Type)
+}
+
+func (t *DataType) decodeNumber(edt []byte) (Value, error) {
+	size := len(edt)
+	code := readUint(edt)
+	signed := strings.HasPrefix(t.Format, "int")
+	if t.OverflowCode && code == overflowCode(size, signed) {
+		return State{Edt: code, Name: "overflow", Description: "Overflow"}, nil
+	}
+	if t.UnderflowCode && code == underflowCode(size, signed) {
+		return State{Edt: code, Name: "underflow", Description: "Underflow"}, nil
+	}
+
+	var value float64
+	if signed {
+		shift := 64 - 8*size
+		value = float64(int64(code<<shift) >> shift)
+	} else {
+		value = float64(code)
+	}
+	if (t.Minimum != nil && value < *t.Minimum) || (t.Maximum != nil && value > *t.Maximum) {
+		return nil, invalidEdt(t, edt)
+	}
+	multiply, divide := t.scale()
+	return Number{Value: value * multiply / divide, Unit: t.Unit, NeedsCoefficient: len(t.Coefficient) > 0}, nil
+}
+
+// overflowCode returns the EDT for an overflow, e.g. 0x7f for int8 and 0xff
+// for uint8.
+func overflowCode(size int, signed bool) uint64 {
+	if signed {
+		return 1<<(8*size-1) - 1
+	}
+	return 1<<(8*size) - 1
+}
+
+// underflowCode returns the EDT for an underflow, e.g. 0x80 for int8 and
+// 0xfe for uint8.
+func underflowCode(size int, signed bool) uint64 {
+	if signed {
+		return 1 << (8*size - 1)
+	}
+	return 1<<(8*size) - 2
+}
+
+// Encode encodes v into an EDT.
+func (t *DataType) Encode(v Value) ([]byte, error) {
+	if len(t.OneOf) > 0 {
+		for _, alt := range t.OneOf {
+			if edt, err := alt.Encode(v); err == nil {
+				return edt, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: no data type matches %v", ErrInvalidValue, v)
+	}
+
+	switch t.Type {
+	case "number":
+		size := numberSizes[t.Format]
+		signed := strings.HasPrefix(t.Format, "int")
+		if s, ok := v.(State); ok && s.Name == "overflow" && t.OverflowCode {
+			return putUint(size, overflowCode(size, signed)), nil
+		}
+		if s, ok := v.(State); ok && s.Name == "underflow" && t.UnderflowCode {
+			return putUint(size, underflowCode(size, signed)), nil
+		}
+		n, ok := v.(Number)
+		if !ok {
+			return nil, invalidValue(t, v)
+		}
+		return t.encodeNumber(n)
+	case "state":
+		s, ok := v.(State)
+		if !ok {
+			return nil, invalidValue(t, v)
+		}
+		for _, e := range t.Enum {
+			if (s.Name != "" && e.Name == s.Name) || (s.Name == "" && e.edt == s.Edt) {
+				return putUint(t.size(), e.edt), nil
+			}
+		}
+		return nil, invalidValue(t, v)
+	case "numericValue":
+		n, ok := v.(Number)
+		if !ok {
+			return nil, invalidValue(t, v)
+		}
+		for _, e := range t.Enum {
+			if e.NumericValue == n.Value {
+				return putUint(t.size(), e.edt), nil
+			}
+		}
+		return nil, invalidValue(t, v)
+	case "level":
+		l, ok := v.(Level)
+		if !ok || l < 1 || float64(l) > *t.Maximum {
+			return nil, invalidValue(t, v)
+		}
+		return []byte{t.base + byte(l) - 1}, nil
+	case "bitmap":
+		b, ok := v.(Bitmap)
+		if !ok {
+			return nil, invalidValue(t, v)
+		}
+		edt := make([]byte, t.size())
+		for _, field := range t.Bitmaps {
+			fv, ok := b[field.Name]
+			if !ok {
+				continue
+			}
+			encoded, err := field.Value.Encode(fv)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", field.Name, err)
+			}
+			if len(encoded) != 1 || encoded[0]<<bits.TrailingZeros8(field.mask)&^field.mask != 0 {
+				return nil, fmt.Errorf("%s: %w", field.Name, invalidValue(t, fv))
+			}
+			edt[field.Position.Index] |= encoded[0] << bits.TrailingZeros8(field.mask)
+		}
+		return edt, nil
+	case "date":
+		d, ok := v.(Date)
+		if !ok || d.Year() < 0 || d.Year() > math.MaxUint16 {
+			return nil, invalidValue(t, v)
+		}
+		return []byte{byte(d.Year() >> 8), byte(d.Year()), byte(d.Month()), byte(d.Day())}, nil
+	case "date-time":
+		dt, ok := v.(DateTime)
+		if !ok || dt.Year() < 0 || dt.Year() > math.MaxUint16 {
+			return nil, invalidValue(t, v)
+		}
+		edt := []byte{byte(dt.Year() >> 8), byte(dt.Year()), byte(dt.Month()), byte(dt.Day()), byte(dt.Hour()), byte(dt.Minute()), byte(dt.Second())}
+		return edt[:t.size()], nil
+	case "time":
+		tv, ok := v.(Time)
+		if !ok || tv.Hour < 0 || tv.Hour > math.MaxUint8 || tv.Minute < 0 || tv.Minute > 59 || tv.Second < 0 || tv.Second > 59 {
+			return nil, invalidValue(t, v)
+		}
+		edt := []byte{byte(tv.Hour), byte(tv.Minute), byte(tv.Second)}
+		return edt[:t.size()], nil
+	case "raw":
+		r, ok := v.(Raw)
+		if !ok || len(r) < t.MinSize || (t.MaxSize > 0 && len(r) > t.MaxSize) {
+			return nil, invalidValue(t, v)
+		}
+		return append([]byte{}, r...), nil
+	case "array":
+		a, ok := v.(Array)
+		if !ok || len(a) < t.MinItems || (t.MaxItems > 0 && len(a) > t.MaxItems) {
+			return nil, invalidValue(t, v)
+		}
+		edt := []byte{}
+		for _, item := range a {
+			encoded, err := t.Items.Encode(item)
+			if err != nil {
+				return nil, err
+			}
+			if len(encoded) != t.ItemSize {
+				return nil, invalidValue(t, item)
+			}
+			edt = append(edt, encoded...)
+		}
+		return edt, nil
+	case "object":
+		o, ok := v.(Object)
+		if !ok {
+			return nil, invalidValue(t, v)
+		}
+		edt := []byte{}
+		for _, element := range t.Properties {
+			ev, ok := o[element.ElementName]
+			if !ok {
+				return nil, fmt.Errorf("%w: no element %s", ErrInvalidValue, element.ElementName)
+			}
+			encoded, err := element.Element.Encode(ev)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", element.ElementName, err)
+			}
+			edt = append(edt, encoded...)
+		}
+		return edt, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownType, t.Type)
+}
+
+func (t *DataType) encodeNumber(n Number) ([]byte, error) {
+	if math.IsNaN(n.Value) || math.IsInf(n.Value, 0) {
+		// NaN is never out of the range
+		return nil, invalidValue(t, n)
+	}
+	multiply, divide := t.scale()
+	value := math.Round(n.Value * divide / multiply)
+	if (t.Minimum != nil && value < *t.Minimum) || (t.Maximum != nil && value > *t.Maximum) {
+		return nil, invalidValue(t, n)
+	}
+
+	size := numberSizes[t.Format]
+	if strings.HasPrefix(t.Format, "int") {
+		limit := float64(int64(1) << (8*size - 1))
+		if value < -limit || value >= limit {
+			return nil, invalidValue(t, n)
+		}
+		return putUint(size, uint64(int64(value))), nil
+	}
+	if value < 0 || value >= float64(uint64(1)<<(8*size)) {
+		return nil, invalidValue(t, n)
+	}
+	return putUint(size, uint64(value)), nil
+}
@@ -0,0 +1,331 @@
+// Package mra decodes and encodes EDTs of any ECHONET Lite property with
+// class definitions in the format of the Machine Readable Appendix (MRA)
+// published by the ECHONET Consortium.
+//
+// The embedded definitions returned by Default are a subset of the MRA which
+// covers the device object super class, the node profile and the classes used
+// in this module. The full MRA can be loaded with LoadDir from its mraData
+// directory.
+package mra
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+var (
+	ErrUnknownProperty = errors.New("unknown property")
+	ErrUnknownType     = errors.New("unknown data type")
+	ErrInvalidEdt      = errors.New("invalid edt")
+	ErrInvalidValue    = errors.New("invalid value")
+)
+
+//go:embed data
+var data embed.FS
+
+// Text is a localized text.
+type Text struct {
+	Ja string `json:"ja"`
+	En string `json:"en"`
+}
+
+// Access is an access rule of a property: "required", "optional" or
+// "notApplicable".
+type Access string
+
+const (
+	AccessRequired      Access = "required"
+	AccessOptional      Access = "optional"
+	AccessNotApplicable Access = "notApplicable"
+)
+
+// Applicable reports whether the access is required or optional.
+func (a Access) Applicable() bool {
+	return a != "" && a != AccessNotApplicable
+}
+
+type AccessRule struct {
+	Get      Access `json:"get"`
+	Set      Access `json:"set"`
+	Announce Access `json:"inf"`
+}
+
+type Property struct {
+	Epc byte
+	// Name is the English name of the property.
+	Name string
+	// ShortName is a lower camel case identifier of the property, e.g.
+	// "operationStatus".
+	ShortName  string
+	AccessRule AccessRule
+	Data       *DataType
+}
+
+// Decode decodes edt of the property.
+func (p *Property) Decode(edt []byte) (Value, error) {
+	return p.Data.Decode(edt)
+}
+
+// Encode encodes v into an EDT of the property.
+func (p *Property) Encode(v Value) ([]byte, error) {
+	return p.Data.Encode(v)
+}
+
+type Class struct {
+	// Code is the class group code and the class code, e.g. 0x0130.
+	Code uint16
+	// Name is the English name of the class.
+	Name string
+	// ShortName is a lower camel case identifier of the class, e.g.
+	// "homeAirConditioner".
+	ShortName string
+	// Properties are the class specific properties sorted by EPCs.
+	Properties []*Property
+}
+
+// Property returns the class specific property epc.
+func (c *Class) Property(epc byte) (*Property, bool) {
+	i, ok := slices.BinarySearchFunc(c.Properties, epc, func(p *Property, epc byte) int {
+		return int(p.Epc) - int(epc)
+	})
+	if !ok {
+		return nil, false
+	}
+	return c.Properties[i], true
+}
+
+// Database is a set of class definitions.
+type Database struct {
+	// SuperClass has the properties which all device objects share.
+	SuperClass *Class
+	classes    map[uint16]*Class
+}
+
+// Default returns the embedded definitions.
+var Default = sync.OnceValue(func() *Database {
+	sub, err := fs.Sub(data, "data")
+	if err != nil {
+		panic(err)
+	}
+	db, err := Load(sub)
+	if err != nil {
+		panic(fmt.Sprintf("mra: invalid embedded definitions: %v", err))
+	}
+	return db
+})
+
+// LoadDir loads the definitions from dir, which is laid out like the mraData
+// directory of the MRA.
+func LoadDir(dir string) (*Database, error) {
+	return Load(os.DirFS(dir))
+}
+
+// Load loads the definitions from fsys. It reads definitions/definitions.json,
+// superClass/0x0000.json, nodeProfile/*.json and devices/*.json.
+func Load(fsys fs.FS) (*Database, error) {
+	var defs struct {
+		Definitions map[string]*DataType `json:"definitions"`
+	}
+	if err := readJSON(fsys, "definitions/definitions.json", &defs); err != nil {
+		return nil, err
+	}
+	resolver := resolver{definitions: defs.Definitions, resolved: map[string]*DataType{}}
+
+	db := &Database{classes: map[uint16]*Class{}}
+	superClass, err := loadClass(fsys, "superClass/0x0000.json", &resolver)
+	if err != nil {
+		return nil, err
+	}
+	db.SuperClass = superClass
+
+	for _, dir := range []string{"nodeProfile", "devices"} {
+		files, err := fs.Glob(fsys, dir+"/*.json")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			class, err := loadClass(fsys, file, &resolver)
+			if err != nil {
+				return nil, err
+			}
+			db.classes[class.Code] = class
+		}
+	}
+	return db, nil
+}
+
+type jsonClass struct {
+	Eoj          string         `json:"eoj"`
+	ClassName    Text           `json:"className"`
+	ShortName    string         `json:"shortName"`
+	ElProperties []jsonProperty `json:"elProperties"`
+}
+
+type jsonProperty struct {
+	Epc          string          `json:"epc"`
+	ValidRelease json.RawMessage `json:"validRelease"`
+	PropertyName Text            `json:"propertyName"`
+	ShortName    string          `json:"shortName"`
+	AccessRule   AccessRule      `json:"accessRule"`
+	Data         *DataType       `json:"data"`
+}
+
+func readJSON(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func loadClass(fsys fs.FS, name string, resolver *resolver) (*Class, error) {
+	var j jsonClass
+	if err := readJSON(fsys, name, &j); err != nil {
+		return nil, err
+	}
+	code, err := parseHex(j.Eoj, 16)
+	if err != nil {
+		return nil, fmt.Errorf("%s: eoj: %w", name, err)
+	}
+
+	// a property is defined for each range of releases and the last one is
+	// the latest
+	properties := map[byte]*Property{}
+	for _, jp := range j.ElProperties {
+		epc, err := parseHex(jp.Epc, 8)
+		if err != nil {
+			return nil, fmt.Errorf("%s: epc: %w", name, err)
+		}
+		dataType, err := resolver.resolve(jp.Data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: epc 0x%02x: %w", path.Base(name), epc, err)
+		}
+		properties[byte(epc)] = &Property{
+			Epc:        byte(epc),
+			Name:       jp.PropertyName.En,
+			ShortName:  jp.ShortName,
+			AccessRule: jp.AccessRule,
+			Data:       dataType,
+		}
+	}
+
+	class := &Class{
+		Code:       uint16(code),
+		Name:       j.ClassName.En,
+		ShortName:  j.ShortName,
+		Properties: maps.Values(properties),
+	}
+	slices.SortFunc(class.Properties, func(a, b *Property) int {
+		return int(a.Epc) - int(b.Epc)
+	})
+	return class, nil
+}
+
+// Class returns the definition of class, which is a class group code and a
+// class code.
+func (db *Database) Class(class uint16) (*Class, bool) {
+	c, ok := db.classes[class]
+	return c, ok
+}
+
+// Classes returns the class group codes and the class codes of the defined
+// classes in ascending order.
+func (db *Database) Classes() []uint16 {
+	classes := maps.Keys(db.classes)
+	slices.Sort(classes)
+	return classes
+}
+
+// Property returns the definition of epc of eoj. A class specific property
+// takes precedence over the property of the super class for device objects.
+func (db *Database) Property(eoj echonetlite.EOJ, epc byte) (*Property, bool) {
+	if class, ok := db.classes[eoj.Class()]; ok {
+		if p, ok := class.Property(epc); ok {
+			return p, true
+		}
+	}
+	if eoj.ClassGroupCode() == 0x0e || db.SuperClass == nil {
+		// profile objects do not inherit the device object super class
+		return nil, false
+	}
+	return db.SuperClass.Property(epc)
+}
+
+// Decode decodes edt of epc of eoj.
+func (db *Database) Decode(eoj echonetlite.EOJ, epc byte, edt []byte) (Value, error) {
+	p, ok := db.Property(eoj, epc)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s 0x%02x", ErrUnknownProperty, eoj, epc)
+	}
+	return p.Decode(edt)
+}
+
+// Encode encodes v into an EDT of epc of eoj.
+func (db *Database) Encode(eoj echonetlite.EOJ, epc byte, v Value) ([]byte, error) {
+	p, ok := db.Property(eoj, epc)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s 0x%02x", ErrUnknownProperty, eoj, epc)
+	}
+	return p.Encode(v)
+}
+
+// Register adds names of the classes and their properties to the registries
+// in echonetlite, and sets echonetlite.PropertyValueFormatter to decode values
+// with db, so that traced frames have decoded values. Existing names are
+// kept, and the hand-written formatters are only used for properties which
+// db doesn't define. Call it before starting controllers since the
+// registries are not guarded.
+func (db *Database) Register() {
+	for code, class := range db.classes {
+		if _, ok := echonetlite.ClassNames[code]; !ok {
+			echonetlite.ClassNames[code] = class.Name
+		}
+		if echonetlite.PropertyNames[code] == nil {
+			echonetlite.PropertyNames[code] = map[byte]string{}
+		}
+		for _, p := range class.Properties {
+			if _, ok := echonetlite.PropertyNames[code][p.Epc]; !ok {
+				echonetlite.PropertyNames[code][p.Epc] = p.Name
+			}
+		}
+	}
+	echonetlite.PropertyValueFormatter = db.formatValue
+}
+
+// formatValue decodes edt for echonetlite.PropertyValueFormatter. An invalid
+// EDT of a known property has no value. A property which the MRA only defines
+// as raw bytes, e.g. a property map, is left to the formatters in echonetlite.
+func (db *Database) formatValue(eoj echonetlite.EOJ, epc byte, edt []byte) (string, bool) {
+	p, ok := db.Property(eoj, epc)
+	if !ok || p.Data.Type == "raw" {
+		return "", false
+	}
+	v, err := p.Decode(edt)
+	if err != nil {
+		return "", true
+	}
+	return v.String(), true
+}
+
+// parseHex parses a hexadecimal string with a "0x" prefix, e.g. "0x80".
+func parseHex(s string, bitSize int) (uint64, error) {
+	if !strings.HasPrefix(s, "0x") && !strings.HasPrefix(s, "0X") {
+		return 0, fmt.Errorf("%w: %q", strconv.ErrSyntax, s)
+	}
+	return strconv.ParseUint(s[2:], 16, bitSize)
+}
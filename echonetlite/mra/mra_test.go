@@ -0,0 +1,223 @@
+package mra_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/mra"
+)
+
+const (
+	aircon      echonetlite.EOJ = 0x013001
+	smartMeter  echonetlite.EOJ = 0x028801
+	nodeProfile echonetlite.EOJ = 0x0ef001
+)
+
+func TestDefault(t *testing.T) {
+	db := mra.Default()
+	class, ok := db.Class(0x0130)
+	if !ok || class.Name != "Home air conditioner" || class.ShortName != "homeAirConditioner" || len(class.Properties) < 40 {
+		t.Fatalf("Class failure: %+v, %v", class, ok)
+	}
+	p, ok := db.Property(aircon, 0xb3)
+	if !ok || p.ShortName != "targetTemperature" || !p.AccessRule.Set.Applicable() {
+		t.Errorf("Property failure: %+v, %v", p, ok)
+	}
+	if p, ok := db.Property(aircon, 0x8a); !ok || p.Name != "Manufacturer code" {
+		t.Errorf("Property failure for super class: %+v, %v", p, ok)
+	}
+	if _, ok := db.Property(nodeProfile, 0x84); ok {
+		t.Errorf("Property failure: node profile has a device property")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	db := mra.Default()
+	tests := []struct {
+		eoj    echonetlite.EOJ
+		epc    byte
+		edt    []byte
+		expect mra.Value
+	}{
+		{aircon, 0x80, []byte{0x30}, mra.State{Edt: 0x30, Name: "true", Description: "ON"}},
+		{aircon, 0xb0, []byte{0x42}, mra.State{Edt: 0x42, Name: "cooling", Description: "Cooling"}},
+		{aircon, 0xb3, []byte{0x1a}, mra.Number{Value: 26, Unit: "Celsius"}},
+		{aircon, 0xb3, []byte{0xfd}, mra.State{Edt: 0xfd, Name: "undefined", Description: "Undefined"}},
+		{aircon, 0xbb, []byte{0xfe}, mra.Number{Value: -2, Unit: "Celsius"}},
+		{aircon, 0xbb, []byte{0x7f}, mra.State{Edt: 0x7f, Name: "overflow", Description: "Overflow"}},
+		{aircon, 0xbf, []byte{0xe7}, mra.Number{Value: -2.5, Unit: "Celsius"}},
+		{aircon, 0xb9, []byte{0x00, 0x7b}, mra.Number{Value: 12.3, Unit: "A"}},
+		{aircon, 0xa0, []byte{0x33}, mra.Level(3)},
+		{aircon, 0xa0, []byte{0x41}, mra.State{Edt: 0x41, Name: "auto", Description: "Automatic"}},
+		{aircon, 0x85, []byte{0x00, 0x01, 0x00, 0x00}, mra.Number{Value: 65.536, Unit: "kWh"}},
+		{aircon, 0x8e, []byte{0x07, 0xe7, 0x0a, 0x11}, mra.Date{time.Date(2023, 10, 17, 0, 0, 0, 0, time.UTC)}},
+		{aircon, 0x91, []byte{0x07, 0x1e}, mra.Time{Hour: 7, Minute: 30}},
+		{aircon, 0xcd, []byte{0x02}, mra.Bitmap{
+			"compressor": mra.State{Edt: 0, Name: "false", Description: "No"},
+			"thermostat": mra.State{Edt: 1, Name: "true", Description: "Yes"},
+		}},
+		{aircon, 0xb8, []byte{0x02, 0x58, 0x03, 0x20, 0x01, 0x90, 0x00, 0x32}, mra.Object{
+			"cooling":       mra.Number{Value: 600, Unit: "W"},
+			"heating":       mra.Number{Value: 800, Unit: "W"},
+			"dehumidifying": mra.Number{Value: 400, Unit: "W"},
+			"circulation":   mra.Number{Value: 50, Unit: "W"},
+		}},
+		{aircon, 0x9a, []byte{0x43, 0x00, 0x00, 0x01, 0x00}, mra.Object{
+			"unit": mra.State{Edt: 0x43, Name: "hour", Description: "Hour"},
+			"time": mra.Number{Value: 256},
+		}},
+		// the coefficient (0xd3) and the unit (0xe1) are other properties
+		{smartMeter, 0xe0, []byte{0x00, 0x00, 0x30, 0x39}, mra.Number{Value: 12345, NeedsCoefficient: true}},
+		{nodeProfile, 0xd6, []byte{0x02, 0x01, 0x30, 0x01, 0x01, 0x30, 0x02}, mra.Object{
+			"numberOfInstances": mra.Number{Value: 2},
+			"instanceList":      mra.Array{mra.Raw{0x01, 0x30, 0x01}, mra.Raw{0x01, 0x30, 0x02}},
+		}},
+	}
+	for _, tt := range tests {
+		v, err := db.Decode(tt.eoj, tt.epc, tt.edt)
+		if err != nil || !reflect.DeepEqual(v, tt.expect) {
+			t.Errorf("Decode failure for 0x%02x 0x%x: %#v, %v", tt.epc, tt.edt, v, err)
+			continue
+		}
+
+		edt, err := db.Encode(tt.eoj, tt.epc, v)
+		if err != nil || !reflect.DeepEqual(edt, tt.edt) {
+			t.Errorf("Encode failure for 0x%02x %v: 0x%x, %v", tt.epc, v, edt, err)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	db := mra.Default()
+	if _, err := db.Decode(aircon, 0xf0, []byte{0x00}); !errors.Is(err, mra.ErrUnknownProperty) {
+		t.Errorf("Decode failure for unknown property: %v", err)
+	}
+	for _, tt := range []struct {
+		epc byte
+		edt []byte
+	}{
+		{0x80, []byte{0x32}},
+		{0xb3, []byte{0x33}},
+		{0xb3, []byte{0x1a, 0x00}},
+		{0xa0, []byte{0x39}},
+		{0x8e, []byte{0x07, 0xe7, 0x02, 0x1e}},
+	} {
+		if _, err := db.Decode(aircon, tt.epc, tt.edt); !errors.Is(err, mra.ErrInvalidEdt) {
+			t.Errorf("Decode failure for 0x%02x 0x%x: %v", tt.epc, tt.edt, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		epc byte
+		v   mra.Value
+	}{
+		{0xb3, mra.Number{Value: 51}},
+		{0xb3, mra.Number{Value: math.NaN()}},
+		{0xb3, mra.Number{Value: math.Inf(-1)}},
+		{0xbb, mra.Number{Value: math.Inf(1)}},
+		{0xb3, mra.Level(1)},
+		{0xa0, mra.Level(9)},
+		{0xb0, mra.State{Name: "drying"}},
+	} {
+		if _, err := db.Encode(aircon, tt.epc, tt.v); !errors.Is(err, mra.ErrInvalidValue) {
+			t.Errorf("Encode failure for 0x%02x %v: %v", tt.epc, tt.v, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"definitions/definitions.json": {Data: []byte(`{"definitions": {
+			"state_ON-OFF_3031": {"type": "state", "size": 1, "enum": [{"edt": "0x30", "name": "true"}, {"edt": "0x31", "name": "false"}]}
+		}}`)},
+		"superClass/0x0000.json": {Data: []byte(`{"eoj": "0x0000", "elProperties": [
+			{"epc": "0x80", "shortName": "operationStatus", "data": {"$ref": "#/definitions/state_ON-OFF_3031"}}
+		]}`)},
+		"devices/0x0288.json": {Data: []byte(`{"eoj": "0x0288", "className": {"en": "Smart meter"}, "elProperties": [
+			{"epc": "0xE1", "shortName": "unitForCumulativeElectricEnergy", "data": {"type": "numericValue", "size": 1, "enum": [
+				{"edt": "0x00", "numericValue": 1}, {"edt": "0x01", "numericValue": 0.1}
+			]}},
+			{"epc": "0xE2", "validRelease": {"from": "A", "to": "B"}, "shortName": "old", "data": {"type": "raw", "minSize": 1, "maxSize": 1}},
+			{"epc": "0xE2", "validRelease": {"from": "C", "to": "latest"}, "shortName": "historicalData", "data": {"type": "object", "properties": [
+				{"elementName": "day", "element": {"type": "number", "format": "uint16", "minimum": 0, "maximum": 99}},
+				{"elementName": "energy", "element": {"type": "array", "itemSize": 4, "minItems": 1, "maxItems": 48,
+					"items": {"type": "number", "format": "uint32", "minimum": 0, "maximum": 99999999}}}
+			]}},
+			{"epc": "0xE3", "shortName": "lastUpdated", "data": {"type": "date-time", "size": 6}}
+		]}`)},
+	}
+	db, err := mra.Load(fsys)
+	if err != nil {
+		t.Fatalf("Load failure: %v", err)
+	}
+	if classes := db.Classes(); !reflect.DeepEqual(classes, []uint16{0x0288}) {
+		t.Errorf("Classes failure: %04x", classes)
+	}
+
+	meter := echonetlite.EOJ(0x028801)
+	if v, err := db.Decode(meter, 0xe1, []byte{0x01}); err != nil || v != (mra.Number{Value: 0.1}) {
+		t.Errorf("Decode failure for numericValue: %v, %v", v, err)
+	}
+	if p, _ := db.Property(meter, 0xe2); p.ShortName != "historicalData" {
+		t.Errorf("Property failure for the latest release: %+v", p)
+	}
+	v, err := db.Decode(meter, 0xe2, []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x0a, 0x00, 0x00, 0x00, 0x0b})
+	if err != nil || v.String() != "{day=1 energy=[10 11]}" {
+		t.Errorf("Decode failure for object: %v, %v", v, err)
+	}
+	v, err = db.Decode(meter, 0xe3, []byte{0x07, 0xe7, 0x0a, 0x11, 0x0c, 0x22})
+	if err != nil || v.String() != "2023-10-17 12:34:00" {
+		t.Errorf("Decode failure for date-time: %v, %v", v, err)
+	}
+	if _, ok := db.Property(meter, 0x80); !ok {
+		t.Errorf("Property failure for super class")
+	}
+
+	fsys["devices/0x0289.json"] = &fstest.MapFile{Data: []byte(`{"eoj": "0x0289", "elProperties": [
+		{"epc": "0x80", "data": {"$ref": "#/definitions/unknown"}}
+	]}`)}
+	if _, err := mra.Load(fsys); !errors.Is(err, mra.ErrUnknownType) {
+		t.Errorf("Load failure for unknown definition: %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	mra.Default().Register()
+
+	frame := echonetlite.Frame{
+		Ehd1: 0x10,
+		Ehd2: 0x81,
+		Edata: echonetlite.SpecifiedMessage{
+			Seoj: aircon,
+			Deoj: 0x05ff01,
+			Esv:  echonetlite.ServiceTypeGetRes,
+			Properties: []echonetlite.Property{
+				{Epc: 0xb5, Edt: []byte{0x1a}},
+				{Epc: 0xbb, Edt: []byte{0x1c}},
+				// decoded with the MRA instead of the formatters in echonetlite
+				{Epc: 0xb3, Edt: []byte{0xfd}},
+				{Epc: 0xb4, Edt: []byte{0x65}},
+				// raw in the MRA
+				{Epc: 0x9f, Edt: []byte{0x03, 0x80, 0x81, 0x9f}},
+			},
+		},
+	}
+	s := frame.String()
+	for _, expect := range []string{
+		"0xb5 Set temperature value in cooling mode=0x1a (26°C)",
+		"0xbb Measured value of room temperature=0x1c (28°C)",
+		"=0xfd (undefined)",
+		"=0x65,",
+		"=0x0380819f ([80 81 9f])",
+	} {
+		if !strings.Contains(s, expect) {
+			t.Errorf("Register failure: %s", s)
+		}
+	}
+}
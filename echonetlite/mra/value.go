@@ -0,0 +1,151 @@
+package mra
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// Value is a decoded EDT. It is one of Number, State, Level, Bitmap, Date,
+// DateTime, Time, Raw, Array and Object.
+type Value interface {
+	fmt.Stringer
+	value()
+}
+
+// Number is a numerical value scaled by multipleOf of the data type.
+type Number struct {
+	Value float64
+	// Unit is the unit in the MRA, e.g. "Celsius", "%" or "kWh".
+	Unit string
+	// NeedsCoefficient is true if Value still needs to be multiplied by the
+	// values of the properties in Coefficient of the data type, e.g. the
+	// coefficient (0xD3) of smart meters, which a decoded EDT doesn't have.
+	// Encode takes such a value as is.
+	NeedsCoefficient bool
+}
+
+// State is a value of an enumeration.
+type State struct {
+	Edt uint64
+	// Name is an identifier of the state in the MRA, e.g. "true" or
+	// "cooling". Encode looks up a state by Name unless it is empty.
+	Name        string
+	Description string
+}
+
+// Level is a level from 1.
+type Level int
+
+// Bitmap has the decoded values of bit fields keyed by their names.
+type Bitmap map[string]Value
+
+// Date is a date. The location is UTC since EDTs have no time zones.
+type Date struct {
+	time.Time
+}
+
+// DateTime is a date and time. The location is UTC since EDTs have no time
+// zones.
+type DateTime struct {
+	time.Time
+}
+
+// Time is a time of day or a relative time.
+type Time struct {
+	Hour   int
+	Minute int
+	Second int
+}
+
+// Raw is an EDT which is not decoded.
+type Raw []byte
+
+type Array []Value
+
+// Object has the decoded values of elements keyed by their names.
+type Object map[string]Value
+
+func (Number) value()   {}
+func (State) value()    {}
+func (Level) value()    {}
+func (Bitmap) value()   {}
+func (Date) value()     {}
+func (DateTime) value() {}
+func (Time) value()     {}
+func (Raw) value()      {}
+func (Array) value()    {}
+func (Object) value()   {}
+
+var unitSymbols = map[string]string{
+	"Celsius": "°C",
+}
+
+func (n Number) String() string {
+	unit := n.Unit
+	if symbol, ok := unitSymbols[unit]; ok {
+		unit = symbol
+	}
+	s := strconv.FormatFloat(n.Value, 'f', -1, 64) + unit
+	if n.NeedsCoefficient {
+		s += "×coefficient"
+	}
+	return s
+}
+
+func (s State) String() string {
+	if s.Name == "" {
+		return fmt.Sprintf("0x%02x", s.Edt)
+	}
+	return s.Name
+}
+
+func (l Level) String() string {
+	return fmt.Sprintf("level %d", int(l))
+}
+
+func (b Bitmap) String() string {
+	return formatFields(b)
+}
+
+func (d Date) String() string {
+	return d.Format(time.DateOnly)
+}
+
+func (d DateTime) String() string {
+	return d.Format(time.DateTime)
+}
+
+func (t Time) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d", t.Hour, t.Minute, t.Second)
+}
+
+func (r Raw) String() string {
+	return fmt.Sprintf("0x%x", []byte(r))
+}
+
+func (a Array) String() string {
+	items := []string{}
+	for _, v := range a {
+		items = append(items, v.String())
+	}
+	return "[" + strings.Join(items, " ") + "]"
+}
+
+func (o Object) String() string {
+	return formatFields(o)
+}
+
+func formatFields(fields map[string]Value) string {
+	names := maps.Keys(fields)
+	slices.Sort(names)
+	s := []string{}
+	for _, name := range names {
+		s = append(s, name+"="+fields[name].String())
+	}
+	return "{" + strings.Join(s, " ") + "}"
+}
@@ -0,0 +1,132 @@
+package echonetlite
+
+var (
+	// ClassNames is a registry of class names keyed by class group codes
+	// and class codes. Packages for device classes may add their names.
+	ClassNames = map[uint16]string{
+		0x0011: "Temperature sensor",
+		0x0012: "Humidity sensor",
+		0x0130: "Home air conditioner",
+		0x0133: "Ventilation fan",
+		0x0135: "Air cleaner",
+		0x0260: "Electrically operated blind/shade",
+		0x026b: "Electric water heater",
+		0x026f: "Electric lock",
+		0x0272: "Instantaneous water heater",
+		0x0279: "Household solar power generation",
+		0x027b: "Floor heater",
+		0x027d: "Storage battery",
+		0x027e: "Electric vehicle charger/discharger",
+		0x0287: "Power distribution board metering",
+		0x0288: "Low-voltage smart electric energy meter",
+		0x0290: "General lighting",
+		0x03b7: "Refrigerator",
+		0x03c5: "Washing machine",
+		0x05ff: "Controller",
+		0x0ef0: "Node profile",
+	}
+
+	// SuperClassPropertyNames is a registry of names of properties which
+	// all device objects share.
+	SuperClassPropertyNames = map[byte]string{
+		0x80: "Operation status",
+		0x81: "Installation location",
+		0x82: "Standard version information",
+		0x83: "Identification number",
+		0x84: "Measured instantaneous power consumption",
+		0x85: "Measured cumulative electric energy consumption",
+		0x86: "Manufacturer's fault code",
+		0x87: "Current limit setting",
+		0x88: "Fault status",
+		0x89: "Fault description",
+		0x8a: "Manufacturer code",
+		0x8b: "Business facility code",
+		0x8c: "Product code",
+		0x8d: "Production number",
+		0x8e: "Production date",
+		0x8f: "Power-saving operation setting",
+		0x93: "Remote control setting",
+		0x97: "Current time setting",
+		0x98: "Current date setting",
+		0x99: "Power limit setting",
+		0x9a: "Cumulative operating time",
+		0x9b: "SetM property map",
+		0x9c: "GetM property map",
+		0x9d: "Status change announcement property map",
+		0x9e: "Set property map",
+		0x9f: "Get property map",
+	}
+
+	// PropertyNames is a registry of names of class specific properties
+	// keyed by class group codes and class codes. They take precedence over
+	// SuperClassPropertyNames.
+	PropertyNames = map[uint16]map[byte]string{
+		0x0130: {
+			0x90: "ON timer-based reservation setting",
+			0x91: "ON timer setting (time)",
+			0x92: "ON timer setting (relative time)",
+			0x94: "OFF timer-based reservation setting",
+			0x95: "OFF timer setting (time)",
+			0x96: "OFF timer setting (relative time)",
+			0xa0: "Air flow rate setting",
+			0xa1: "Automatic control of air flow direction setting",
+			0xa3: "Automatic swing of air flow setting",
+			0xa4: "Air flow direction (vertical) setting",
+			0xa5: "Air flow direction (horizontal) setting",
+			0xb0: "Operation mode setting",
+			0xb1: "Automatic temperature control setting",
+			0xb2: "Normal/high-speed/silent operation setting",
+			0xb3: "Set temperature value",
+			0xb4: "Set value of relative humidity in dehumidifying mode",
+			0xb5: "Set temperature value in cooling mode",
+			0xb6: "Set temperature value in heating mode",
+			0xb7: "Set temperature value in dehumidifying mode",
+			0xb8: "Rated power consumption",
+			0xb9: "Measured value of current consumption",
+			0xba: "Measured value of room relative humidity",
+			0xbb: "Measured value of room temperature",
+			0xbc: "Set temperature value of user remote control",
+			0xbd: "Measured cooled air temperature",
+			0xbe: "Measured outdoor air temperature",
+			0xbf: "Relative temperature setting",
+			0xc0: "Ventilation function setting",
+			0xc1: "Humidifier function setting",
+			0xc2: "Ventilation air flow rate setting",
+			0xc4: "Degree of humidification setting",
+			0xc6: "Mounted air cleaning method",
+			0xc7: "Air purifier function setting",
+			0xc8: "Mounted air refreshing method",
+			0xc9: "Air refresher function setting",
+			0xca: "Mounted self-cleaning method",
+			0xcb: "Self-cleaning function setting",
+			0xcc: "Special function setting",
+			0xcd: "Operation status of components",
+			0xce: "Thermostat setting override function",
+			0xcf: "Air purification mode setting",
+		},
+		0x0ef0: {
+			0x80: "Operating status",
+			0x82: "Version information",
+			0x83: "Identification number",
+			0x89: "Fault content",
+			0xbf: "Unique identifier data",
+			0xd3: "Number of self-node instances",
+			0xd4: "Number of self-node classes",
+			0xd5: "Instance list notification",
+			0xd6: "Self-node instance list S",
+			0xd7: "Self-node class list S",
+		},
+	}
+)
+
+// PropertyName returns the name of epc of eoj, or an empty string if it is
+// unknown.
+func PropertyName(eoj EOJ, epc byte) string {
+	if name, ok := PropertyNames[eoj.Class()][epc]; ok {
+		return name
+	}
+	if epc >= 0x80 && epc <= 0x9f {
+		return SuperClassPropertyNames[epc]
+	}
+	return ""
+}
@@ -0,0 +1,382 @@
+package echonetlite
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+const (
+	ObjectNodeProfile EOJ = 0x0ef001
+	ObjectController  EOJ = 0x05ff01
+)
+
+const (
+	EpcOperatingStatus           byte = 0x80
+	EpcInstallationLocation      byte = 0x81
+	EpcVersionInformation        byte = 0x82
+	EpcIdentificationNumber      byte = 0x83
+	EpcFaultStatus               byte = 0x88
+	EpcManufacturerCode          byte = 0x8a
+	EpcAnnouncePropertyMap       byte = 0x9d
+	EpcSetPropertyMap            byte = 0x9e
+	EpcGetPropertyMap            byte = 0x9f
+	EpcNumberOfSelfNodeInstances byte = 0xd3
+	EpcNumberOfSelfNodeClasses   byte = 0xd4
+	EpcInstanceListNotification  byte = 0xd5
+	EpcSelfNodeInstanceListS     byte = 0xd6
+	EpcSelfNodeClassListS        byte = 0xd7
+)
+
+const (
+	maxInstanceListCount = 84
+	maxClassListCount    = 8
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+)
+
+// LocalObject is an ECHONET Lite object hosted by a Node.
+// The property maps (0x9d-0x9f) are generated from Properties, SetEpcs and
+// AnnounceEpcs.
+type LocalObject struct {
+	Eoj          EOJ
+	Properties   map[byte][]byte
+	SetEpcs      []byte
+	AnnounceEpcs []byte
+	// Validate reports whether edt can be set to epc in SetEpcs.
+	// Any value is accepted if it is nil.
+	Validate func(epc byte, edt []byte) bool
+}
+
+func (o *LocalObject) set(epc byte, edt []byte) bool {
+	if !slices.Contains(o.SetEpcs, epc) {
+		return false
+	}
+	if o.Validate != nil && !o.Validate(epc, edt) {
+		return false
+	}
+	o.Properties[epc] = slices.Clone(edt)
+	return true
+}
+
+func (o *LocalObject) getEpcs() []byte {
+	epcs := maps.Keys(o.Properties)
+	epcs = append(epcs, EpcAnnouncePropertyMap, EpcSetPropertyMap, EpcGetPropertyMap)
+	slices.Sort(epcs)
+	return slices.Compact(epcs)
+}
+
+func (o *LocalObject) get(epc byte) ([]byte, bool) {
+	var epcs []byte
+	switch epc {
+	case EpcAnnouncePropertyMap:
+		epcs = o.AnnounceEpcs
+	case EpcSetPropertyMap:
+		epcs = o.SetEpcs
+	case EpcGetPropertyMap:
+		epcs = o.getEpcs()
+	default:
+		edt, ok := o.Properties[epc]
+		return edt, ok
+	}
+
+	// a map with an invalid EPC is not available rather than wrong
+	edt, err := EncodePropertyMap(epcs)
+	return edt, err == nil
+}
+
+type NodeConfig struct {
+	ManufacturerCode [3]byte
+	UniqueID         [13]byte
+}
+
+var DefaultNodeConfig = NodeConfig{
+	// 0xffffff is reserved for experimental use
+	ManufacturerCode: [3]byte{0xff, 0xff, 0xff},
+}
+
+// Node hosts a node profile object and device objects, and answers Get and
+// InfReq requests to them.
+type Node struct {
+	m       sync.RWMutex
+	profile *LocalObject
+	objects []*LocalObject
+}
+
+func NewNode(config NodeConfig, objects ...*LocalObject) *Node {
+	n := &Node{
+		profile: &LocalObject{
+			Eoj: ObjectNodeProfile,
+			Properties: map[byte][]byte{
+				// on
+				EpcOperatingStatus: {0x30},
+				// version 1.13, specified message format only
+				EpcVersionInformation: {0x01, 0x0d, 0x01, 0x00},
+				EpcIdentificationNumber: append(
+					append([]byte{0xfe}, config.ManufacturerCode[:]...),
+					config.UniqueID[:]...,
+				),
+				EpcManufacturerCode: config.ManufacturerCode[:],
+			},
+			SetEpcs:      []byte{},
+			AnnounceEpcs: []byte{EpcOperatingStatus, EpcInstanceListNotification},
+		},
+		objects: objects,
+	}
+	n.updateInstanceLists()
+	return n
+}
+
+// NewControllerObject creates a controller object (0x05ff01) with the
+// properties required for the device object super class.
+func NewControllerObject(config NodeConfig) *LocalObject {
+	return &LocalObject{
+		Eoj: ObjectController,
+		Properties: map[byte][]byte{
+			// on
+			EpcOperatingStatus: {0x30},
+			// installation location is not specified
+			EpcInstallationLocation: {0x00},
+			// appendix release R
+			EpcVersionInformation: {0x00, 0x00, 'R', 0x01},
+			// no fault has occurred
+			EpcFaultStatus:      {0x42},
+			EpcManufacturerCode: config.ManufacturerCode[:],
+		},
+		SetEpcs:      []byte{},
+		AnnounceEpcs: []byte{EpcOperatingStatus},
+	}
+}
+
+func (n *Node) AddObject(o *LocalObject) {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	n.objects = append(n.objects, o)
+	n.updateInstanceLists()
+}
+
+func (n *Node) Objects() []EOJ {
+	n.m.RLock()
+	defer n.m.RUnlock()
+
+	eojs := []EOJ{}
+	for _, o := range n.objects {
+		eojs = append(eojs, o.Eoj)
+	}
+	return eojs
+}
+
+func (n *Node) Property(eoj EOJ, epc byte) ([]byte, bool) {
+	n.m.RLock()
+	defer n.m.RUnlock()
+
+	o := n.findObject(eoj)
+	if o == nil {
+		return nil, false
+	}
+	edt, ok := o.get(epc)
+	return slices.Clone(edt), ok
+}
+
+func (n *Node) SetProperty(eoj EOJ, epc byte, edt []byte) error {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	o := n.findObject(eoj)
+	if o == nil {
+		return ErrObjectNotFound
+	}
+	o.Properties[epc] = slices.Clone(edt)
+	return nil
+}
+
+func (n *Node) findObject(eoj EOJ) *LocalObject {
+	if eoj == n.profile.Eoj {
+		return n.profile
+	}
+	for _, o := range n.objects {
+		if o.Eoj == eoj {
+			return o
+		}
+	}
+	return nil
+}
+
+// matchObjects returns objects addressed by eoj. An instance code of 0
+// addresses all instances of the class.
+func (n *Node) matchObjects(eoj EOJ) []*LocalObject {
+	matched := []*LocalObject{}
+	for _, o := range append([]*LocalObject{n.profile}, n.objects...) {
+		if eoj.Matches(o.Eoj) {
+			matched = append(matched, o)
+		}
+	}
+	return matched
+}
+
+func (n *Node) updateInstanceLists() {
+	instances := []byte{}
+	classes := []byte{}
+	seenClasses := map[uint16]bool{}
+	for _, o := range n.objects {
+		instances = append(instances, o.Eoj.bytes()...)
+		if !seenClasses[o.Eoj.Class()] {
+			seenClasses[o.Eoj.Class()] = true
+			classes = append(classes, o.Eoj.ClassGroupCode(), o.Eoj.ClassCode())
+		}
+	}
+
+	instanceCount := len(n.objects)
+	classCount := len(seenClasses)
+	listedInstances := min(instanceCount, maxInstanceListCount)
+	listedClasses := min(classCount, maxClassListCount)
+	instanceList := append([]byte{byte(listedInstances)}, instances[:3*listedInstances]...)
+	classList := append([]byte{byte(listedClasses)}, classes[:2*listedClasses]...)
+
+	props := n.profile.Properties
+	props[EpcNumberOfSelfNodeInstances] = []byte{byte(instanceCount >> 16), byte(instanceCount >> 8), byte(instanceCount)}
+	// the number of classes includes the node profile class
+	props[EpcNumberOfSelfNodeClasses] = []byte{byte((classCount + 1) >> 8), byte(classCount + 1)}
+	props[EpcInstanceListNotification] = instanceList
+	props[EpcSelfNodeInstanceListS] = instanceList
+	props[EpcSelfNodeClassListS] = classList
+}
+
+type outgoingFrame struct {
+	// addr is nil if the frame is sent to the multicast group
+	addr  *net.UDPAddr
+	frame Frame
+}
+
+// handle builds responses to requests addressed to objects in the node.
+func (n *Node) handle(addr net.UDPAddr, f Frame) []outgoingFrame {
+	switch f.Edata.Esv {
+	case ServiceTypeGet, ServiceTypeInfReq:
+		return n.handleGet(addr, f)
+	case ServiceTypeSetI, ServiceTypeSetC, ServiceTypeSetGet:
+		return n.handleSet(addr, f)
+	default:
+		return nil
+	}
+}
+
+func newResponseFrame(o *LocalObject, req Frame) Frame {
+	return Frame{
+		Ehd1: 0x10,
+		Ehd2: 0x81,
+		Tid:  req.Tid,
+		Edata: SpecifiedMessage{
+			Seoj:       o.Eoj,
+			Deoj:       req.Edata.Seoj,
+			Properties: []Property{},
+		},
+	}
+}
+
+// getProperties reads properties requested by Get, InfReq or the get block of
+// SetGet. Unavailable properties are returned with an empty EDT.
+func getProperties(o *LocalObject, requested []Property) ([]Property, bool) {
+	props := []Property{}
+	succeeded := true
+	for _, prop := range requested {
+		edt, ok := o.get(prop.Epc)
+		if !ok {
+			succeeded = false
+			edt = []byte{}
+		}
+		props = append(props, Property{Epc: prop.Epc, Edt: slices.Clone(edt)})
+	}
+	return props, succeeded
+}
+
+func (n *Node) handleGet(addr net.UDPAddr, f Frame) []outgoingFrame {
+	n.m.RLock()
+	defer n.m.RUnlock()
+
+	out := []outgoingFrame{}
+	for _, o := range n.matchObjects(f.Edata.Deoj) {
+		res := newResponseFrame(o, f)
+		props, succeeded := getProperties(o, f.Edata.Properties)
+		res.Edata.Properties = props
+
+		switch {
+		case f.Edata.Esv == ServiceTypeGet && succeeded:
+			res.Edata.Esv = ServiceTypeGetRes
+			out = append(out, outgoingFrame{addr: &addr, frame: res})
+		case f.Edata.Esv == ServiceTypeGet:
+			res.Edata.Esv = ServiceTypeGetSna
+			out = append(out, outgoingFrame{addr: &addr, frame: res})
+		case succeeded:
+			res.Edata.Esv = ServiceTypeInf
+			out = append(out, outgoingFrame{addr: nil, frame: res})
+		default:
+			res.Edata.Esv = ServiceTypeInfSna
+			out = append(out, outgoingFrame{addr: &addr, frame: res})
+		}
+	}
+	return out
+}
+
+// handleSet applies SetI, SetC and SetGet requests. Accepted properties are
+// echoed back with an empty EDT and rejected ones with the requested EDT.
+// Changes of properties in the announce map are notified with Inf.
+func (n *Node) handleSet(addr net.UDPAddr, f Frame) []outgoingFrame {
+	n.m.Lock()
+	defer n.m.Unlock()
+
+	out := []outgoingFrame{}
+	for _, o := range n.matchObjects(f.Edata.Deoj) {
+		res := newResponseFrame(o, f)
+		announce := newResponseFrame(o, f)
+		announce.Edata.Deoj = ObjectNodeProfile
+		announce.Edata.Esv = ServiceTypeInf
+
+		succeeded := true
+		for _, prop := range f.Edata.Properties {
+			previous, _ := o.get(prop.Epc)
+			if !o.set(prop.Epc, prop.Edt) {
+				succeeded = false
+				res.Edata.Properties = append(res.Edata.Properties, Property{Epc: prop.Epc, Edt: slices.Clone(prop.Edt)})
+				continue
+			}
+			res.Edata.Properties = append(res.Edata.Properties, Property{Epc: prop.Epc, Edt: []byte{}})
+			if slices.Contains(o.AnnounceEpcs, prop.Epc) && !slices.Equal(previous, prop.Edt) {
+				announce.Edata.Properties = append(announce.Edata.Properties, Property{Epc: prop.Epc, Edt: slices.Clone(prop.Edt)})
+			}
+		}
+
+		switch f.Edata.Esv {
+		case ServiceTypeSetI:
+			if !succeeded {
+				res.Edata.Esv = ServiceTypeSetISna
+				out = append(out, outgoingFrame{addr: &addr, frame: res})
+			}
+		case ServiceTypeSetC:
+			res.Edata.Esv = ServiceTypeSetReq
+			if !succeeded {
+				res.Edata.Esv = ServiceTypeSetCSna
+			}
+			out = append(out, outgoingFrame{addr: &addr, frame: res})
+		case ServiceTypeSetGet:
+			props, ok := getProperties(o, f.Edata.GetProperties)
+			res.Edata.GetProperties = props
+			res.Edata.Esv = ServiceTypeSetGetRes
+			if !succeeded || !ok {
+				res.Edata.Esv = ServiceTypeSetGetSna
+			}
+			out = append(out, outgoingFrame{addr: &addr, frame: res})
+		}
+
+		if len(announce.Edata.Properties) > 0 {
+			announce.Tid = 0
+			out = append(out, outgoingFrame{addr: nil, frame: announce})
+		}
+	}
+	return out
+}
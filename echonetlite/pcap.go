@@ -0,0 +1,385 @@
+package echonetlite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	linkTypeEthernet = 1
+	linkTypeRaw      = 101
+	linkTypeIPv4     = 228
+	linkTypeIPv6     = 229
+
+	pcapMagic      = 0xa1b2c3d4
+	pcapMagicNano  = 0xa1b23c4d
+	pcapSnapLength = 65535
+
+	pcapngSectionHeader       = 0x0a0d0d0a
+	pcapngInterfaceDesc       = 0x00000001
+	pcapngSimplePacket        = 0x00000003
+	pcapngEnhancedPacket      = 0x00000006
+	pcapngByteOrderMagic      = 0x1a2b3c4d
+	pcapngOptionEnd           = 0
+	pcapngOptionEpbFlags      = 2
+	pcapngOptionIfTsresol     = 9
+	pcapngFlagsInbound        = 0x1
+	pcapngFlagsOutbound       = 0x2
+	pcapngFlagsDirectionMask  = 0x3
+	pcapngDefaultTsResolution = 6
+
+	// captureMaxRecord is the largest record ReadCapture accepts, which is
+	// the default snapshot length of tcpdump, so a corrupt length doesn't
+	// allocate a huge buffer.
+	captureMaxRecord = 262144
+)
+
+// PcapWriter writes packets to a pcap file with synthesized IP and UDP
+// headers. Directions are not recorded since pcap has no field for them.
+type PcapWriter struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+// NewPcapWriter writes a pcap file header to w.
+func NewPcapWriter(w io.Writer) (*PcapWriter, error) {
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(header[4:], 2)
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], pcapSnapLength)
+	binary.LittleEndian.PutUint32(header[20:], linkTypeRaw)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &PcapWriter{w: w}, nil
+}
+
+func (w *PcapWriter) WritePacket(p CapturedPacket) error {
+	data := encodeIPPacket(p)
+	record := make([]byte, 16, 16+len(data))
+	binary.LittleEndian.PutUint32(record[0:], uint32(p.Time.Unix()))
+	binary.LittleEndian.PutUint32(record[4:], uint32(p.Time.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(record[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[12:], uint32(len(data)))
+	record = append(record, data...)
+
+	w.m.Lock()
+	defer w.m.Unlock()
+	_, err := w.w.Write(record)
+	return err
+}
+
+// PcapngWriter writes packets to a pcapng file with synthesized IP and UDP
+// headers. Directions are recorded in the flags of enhanced packet blocks.
+type PcapngWriter struct {
+	m sync.Mutex
+	w io.Writer
+}
+
+// NewPcapngWriter writes a section header block and an interface
+// description block to w.
+func NewPcapngWriter(w io.Writer) (*PcapngWriter, error) {
+	shb := make([]byte, 16)
+	binary.LittleEndian.PutUint32(shb[0:], pcapngByteOrderMagic)
+	binary.LittleEndian.PutUint16(shb[4:], 1)
+	binary.LittleEndian.PutUint64(shb[8:], math.MaxUint64)
+	if _, err := w.Write(pcapngBlock(pcapngSectionHeader, shb)); err != nil {
+		return nil, err
+	}
+
+	idb := make([]byte, 8)
+	binary.LittleEndian.PutUint16(idb[0:], linkTypeRaw)
+	binary.LittleEndian.PutUint32(idb[4:], pcapSnapLength)
+	if _, err := w.Write(pcapngBlock(pcapngInterfaceDesc, idb)); err != nil {
+		return nil, err
+	}
+	return &PcapngWriter{w: w}, nil
+}
+
+func (w *PcapngWriter) WritePacket(p CapturedPacket) error {
+	data := encodeIPPacket(p)
+	ts := uint64(p.Time.UnixMicro())
+
+	body := make([]byte, 20, 20+len(data)+16)
+	binary.LittleEndian.PutUint32(body[4:], uint32(ts>>32))
+	binary.LittleEndian.PutUint32(body[8:], uint32(ts))
+	binary.LittleEndian.PutUint32(body[12:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(body[16:], uint32(len(data)))
+	body = append(body, data...)
+	body = append(body, make([]byte, pad4(len(data)))...)
+
+	var flags uint32
+	switch p.Direction {
+	case DirectionInbound:
+		flags = pcapngFlagsInbound
+	case DirectionOutbound:
+		flags = pcapngFlagsOutbound
+	}
+	if flags != 0 {
+		option := make([]byte, 8)
+		binary.LittleEndian.PutUint16(option[0:], pcapngOptionEpbFlags)
+		binary.LittleEndian.PutUint16(option[2:], 4)
+		binary.LittleEndian.PutUint32(option[4:], flags)
+		body = append(body, option...)
+		body = append(body, 0, 0, 0, 0) // opt_endofopt
+	}
+
+	w.m.Lock()
+	defer w.m.Unlock()
+	_, err := w.w.Write(pcapngBlock(pcapngEnhancedPacket, body))
+	return err
+}
+
+func pcapngBlock(blockType uint32, body []byte) []byte {
+	length := uint32(12 + len(body))
+	block := make([]byte, 8, length)
+	binary.LittleEndian.PutUint32(block[0:], blockType)
+	binary.LittleEndian.PutUint32(block[4:], length)
+	block = append(block, body...)
+	return binary.LittleEndian.AppendUint32(block, length)
+}
+
+func pad4(n int) int {
+	return (4 - n%4) % 4
+}
+
+// ReadCapture reads UDP datagrams from a pcap or pcapng file. Packets which
+// are not UDP datagrams over IPv4 or IPv6 are skipped. Ethernet, raw IP,
+// IPv4 and IPv6 link types are supported.
+func ReadCapture(r io.Reader) ([]CapturedPacket, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, ErrUnsupportedCapture
+	}
+	if binary.LittleEndian.Uint32(magic) == pcapngSectionHeader {
+		return readPcapng(br)
+	}
+	return readPcap(br)
+}
+
+func readPcap(r io.Reader) ([]CapturedPacket, error) {
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrUnsupportedCapture
+	}
+
+	var order binary.ByteOrder
+	var nano bool
+	switch {
+	case binary.LittleEndian.Uint32(header) == pcapMagic:
+		order = binary.LittleEndian
+	case binary.BigEndian.Uint32(header) == pcapMagic:
+		order = binary.BigEndian
+	case binary.LittleEndian.Uint32(header) == pcapMagicNano:
+		order, nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(header) == pcapMagicNano:
+		order, nano = binary.BigEndian, true
+	default:
+		return nil, ErrUnsupportedCapture
+	}
+	linkType := order.Uint32(header[20:]) & 0xffff
+
+	packets := []CapturedPacket{}
+	record := make([]byte, 16)
+	offset := len(header)
+	for {
+		if _, err := io.ReadFull(r, record); errors.Is(err, io.EOF) {
+			return packets, nil
+		} else if err != nil {
+			return packets, ErrTruncated
+		}
+		captured := order.Uint32(record[8:])
+		if captured > captureMaxRecord {
+			return packets, &DecodeError{Field: "record", Offset: offset, Err: ErrWrongLength}
+		}
+		data := make([]byte, captured)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return packets, ErrTruncated
+		}
+		offset += len(record) + len(data)
+
+		p, err := decodeLinkLayer(linkType, data)
+		if err != nil {
+			continue
+		}
+		subsec := time.Duration(order.Uint32(record[4:])) * time.Microsecond
+		if nano {
+			subsec = time.Duration(order.Uint32(record[4:]))
+		}
+		p.Time = time.Unix(int64(order.Uint32(record[0:])), int64(subsec))
+		packets = append(packets, p)
+	}
+}
+
+type pcapngInterface struct {
+	linkType uint32
+	// unit is the duration of a timestamp unit.
+	unit time.Duration
+}
+
+func readPcapng(r io.Reader) ([]CapturedPacket, error) {
+	packets := []CapturedPacket{}
+	var order binary.ByteOrder = binary.LittleEndian
+	interfaces := []pcapngInterface{}
+
+	header := make([]byte, 8)
+	offset := 0
+	for {
+		if _, err := io.ReadFull(r, header); errors.Is(err, io.EOF) {
+			return packets, nil
+		} else if err != nil {
+			return packets, ErrTruncated
+		}
+
+		blockType := order.Uint32(header)
+		if blockType == pcapngSectionHeader {
+			// the byte order of a section is determined by its header
+			magic := make([]byte, 4)
+			if _, err := io.ReadFull(r, magic); err != nil {
+				return packets, ErrTruncated
+			}
+			switch {
+			case binary.LittleEndian.Uint32(magic) == pcapngByteOrderMagic:
+				order = binary.LittleEndian
+			case binary.BigEndian.Uint32(magic) == pcapngByteOrderMagic:
+				order = binary.BigEndian
+			default:
+				return packets, ErrUnsupportedCapture
+			}
+			length := int(order.Uint32(header[4:]))
+			if length < 28 || length%4 != 0 {
+				return packets, ErrUnsupportedCapture
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(length-12)); err != nil {
+				return packets, ErrTruncated
+			}
+			offset += length
+			interfaces = []pcapngInterface{}
+			continue
+		}
+
+		// a block has the type, the length and the trailing length
+		length := int(order.Uint32(header[4:]))
+		if length < 12 || length%4 != 0 || length > captureMaxRecord {
+			return packets, &DecodeError{Field: "block", Offset: offset, Err: ErrWrongLength}
+		}
+		body := make([]byte, length-8)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return packets, ErrTruncated
+		}
+		body = body[:len(body)-4]
+		offset += length
+
+		switch blockType {
+		case pcapngInterfaceDesc:
+			if len(body) < 8 {
+				return packets, ErrUnsupportedCapture
+			}
+			interfaces = append(interfaces, pcapngInterface{
+				linkType: uint32(order.Uint16(body)),
+				unit:     pcapngTimestampUnit(order, body[8:]),
+			})
+		case pcapngEnhancedPacket:
+			if len(body) < 20 {
+				return packets, ErrUnsupportedCapture
+			}
+			id := int(order.Uint32(body))
+			captured := int(order.Uint32(body[12:]))
+			if id >= len(interfaces) || 20+captured > len(body) {
+				return packets, ErrUnsupportedCapture
+			}
+			p, err := decodeLinkLayer(interfaces[id].linkType, body[20:20+captured])
+			if err != nil {
+				continue
+			}
+			ts := uint64(order.Uint32(body[4:]))<<32 | uint64(order.Uint32(body[8:]))
+			p.Time = time.Unix(0, 0).Add(time.Duration(ts) * interfaces[id].unit)
+			options := body[20+captured+pad4(captured):]
+			p.Direction = pcapngDirection(order, options)
+			packets = append(packets, p)
+		case pcapngSimplePacket:
+			if len(body) < 4 || len(interfaces) < 1 {
+				return packets, ErrUnsupportedCapture
+			}
+			original := int(order.Uint32(body))
+			data := body[4:]
+			if original < len(data) {
+				data = data[:original]
+			}
+			if p, err := decodeLinkLayer(interfaces[0].linkType, data); err == nil {
+				packets = append(packets, p)
+			}
+		}
+	}
+}
+
+// pcapngOptions calls f for each option in options until it returns false.
+func pcapngOptions(order binary.ByteOrder, options []byte, f func(code uint16, value []byte) bool) {
+	for len(options) >= 4 {
+		code := order.Uint16(options)
+		length := int(order.Uint16(options[2:]))
+		if code == pcapngOptionEnd || 4+length > len(options) {
+			return
+		}
+		if !f(code, options[4:4+length]) {
+			return
+		}
+		options = options[min(len(options), 4+length+pad4(length)):]
+	}
+}
+
+func pcapngTimestampUnit(order binary.ByteOrder, options []byte) time.Duration {
+	resolution := byte(pcapngDefaultTsResolution)
+	pcapngOptions(order, options, func(code uint16, value []byte) bool {
+		if code == pcapngOptionIfTsresol && len(value) >= 1 {
+			resolution = value[0]
+			return false
+		}
+		return true
+	})
+
+	if resolution&0x80 != 0 {
+		// a negative power of 2
+		return time.Duration(float64(time.Second) / math.Pow(2, float64(resolution&0x7f)))
+	}
+	unit := time.Second
+	for i := byte(0); i < resolution && unit > 1; i++ {
+		unit /= 10
+	}
+	return unit
+}
+
+func pcapngDirection(order binary.ByteOrder, options []byte) Direction {
+	direction := DirectionUnknown
+	pcapngOptions(order, options, func(code uint16, value []byte) bool {
+		if code == pcapngOptionEpbFlags && len(value) >= 4 {
+			switch order.Uint32(value) & pcapngFlagsDirectionMask {
+			case pcapngFlagsInbound:
+				direction = DirectionInbound
+			case pcapngFlagsOutbound:
+				direction = DirectionOutbound
+			}
+			return false
+		}
+		return true
+	})
+	return direction
+}
+
+func decodeLinkLayer(linkType uint32, data []byte) (CapturedPacket, error) {
+	switch linkType {
+	case linkTypeEthernet:
+		return decodeEthernetFrame(data)
+	case linkTypeRaw, linkTypeIPv4, linkTypeIPv6:
+		return decodeIPPacket(data)
+	default:
+		return CapturedPacket{}, ErrUnsupportedCapture
+	}
+}
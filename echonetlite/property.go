@@ -1,6 +1,11 @@
 package echonetlite
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
 
 var (
 	ErrUnexpectedEpc           = errors.New("unexpected epc")
@@ -11,14 +16,24 @@ func GetPropertyMap(p Property) ([]byte, error) {
 	if p.Epc < 0x9b || p.Epc > 0x9f {
 		return nil, ErrUnexpectedEpc
 	}
+	if len(p.Edt) < 1 {
+		return nil, &DecodeError{Field: "property map", Offset: 0, Err: ErrTruncated}
+	}
 
 	propCount := int(p.Edt[0])
-	if propCount <= 16 {
+	if propCount < 16 {
+		if len(p.Edt) < 1+propCount {
+			return nil, &DecodeError{Field: "property map", Offset: 1, Err: ErrTruncated}
+		}
 		list := make([]byte, propCount)
 		copy(list, p.Edt[1:])
 		return list, nil
 	}
 
+	if len(p.Edt) < 17 {
+		return nil, &DecodeError{Field: "property map", Offset: 1, Err: ErrTruncated}
+	}
+
 	list := []byte{}
 	for i := byte(0); i < 16; i++ {
 		b := p.Edt[1+i]
@@ -53,3 +68,26 @@ func GetPropertyMap(p Property) ([]byte, error) {
 	}
 	return list, nil
 }
+
+// EncodePropertyMap encodes epcs into the EDT of a property map (0x9b-0x9f).
+// Duplicated EPCs are encoded once. It returns ErrUnexpectedEpc for an EPC
+// below 0x80, which property maps can't describe.
+func EncodePropertyMap(epcs []byte) ([]byte, error) {
+	epcs = slices.Clone(epcs)
+	slices.Sort(epcs)
+	epcs = slices.Compact(epcs)
+	if len(epcs) > 0 && epcs[0] < 0x80 {
+		return nil, fmt.Errorf("%w: 0x%02x", ErrUnexpectedEpc, epcs[0])
+	}
+
+	if len(epcs) < 16 {
+		return append([]byte{byte(len(epcs))}, epcs...), nil
+	}
+
+	data := make([]byte, 17)
+	data[0] = byte(len(epcs))
+	for _, epc := range epcs {
+		data[1+epc&0x0f] |= 1 << ((epc >> 4) - 8)
+	}
+	return data, nil
+}
@@ -0,0 +1,82 @@
+package echonetlite_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+func TestPropertyMap(t *testing.T) {
+	t.Run("List", func(t *testing.T) {
+		epcs := []byte{0x80, 0x81, 0x9f}
+		edt, err := echonetlite.EncodePropertyMap(epcs)
+		if err != nil || !reflect.DeepEqual(edt, []byte{0x03, 0x80, 0x81, 0x9f}) {
+			t.Errorf("EncodePropertyMap failure: %x, %v", edt, err)
+		}
+		actual, err := echonetlite.GetPropertyMap(echonetlite.Property{Epc: 0x9f, Edt: edt})
+		if err != nil {
+			t.Errorf("GetPropertyMap failure: %v", err)
+		}
+		if !reflect.DeepEqual(actual, epcs) {
+			t.Errorf("GetPropertyMap failure")
+		}
+	})
+
+	t.Run("Bitmap", func(t *testing.T) {
+		epcs := []byte{}
+		for epc := 0x80; epc < 0x90; epc++ {
+			epcs = append(epcs, byte(epc))
+		}
+		epcs = append(epcs, 0xb3, 0xff)
+		edt, err := echonetlite.EncodePropertyMap(epcs)
+		if err != nil || len(edt) != 17 || edt[0] != byte(len(epcs)) {
+			t.Errorf("EncodePropertyMap failure: %x, %v", edt, err)
+		}
+		actual, err := echonetlite.GetPropertyMap(echonetlite.Property{Epc: 0x9f, Edt: edt})
+		if err != nil {
+			t.Errorf("GetPropertyMap failure: %v", err)
+		}
+		if len(actual) != len(epcs) {
+			t.Errorf("GetPropertyMap failure")
+		}
+		for _, epc := range epcs {
+			found := false
+			for _, a := range actual {
+				found = found || a == epc
+			}
+			if !found {
+				t.Errorf("GetPropertyMap failure: %02x is missing", epc)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if edt, err := echonetlite.EncodePropertyMap([]byte{0x80, 0x7f}); !errors.Is(err, echonetlite.ErrUnexpectedEpc) {
+			t.Errorf("EncodePropertyMap failure for 0x7f: %x, %v", edt, err)
+		}
+		epcs := []byte{0x01}
+		for epc := 0x80; epc < 0x90; epc++ {
+			epcs = append(epcs, byte(epc))
+		}
+		if edt, err := echonetlite.EncodePropertyMap(epcs); !errors.Is(err, echonetlite.ErrUnexpectedEpc) {
+			t.Errorf("EncodePropertyMap failure for 0x01: %x, %v", edt, err)
+		}
+	})
+
+	t.Run("Duplicated", func(t *testing.T) {
+		edt, err := echonetlite.EncodePropertyMap([]byte{0x9f, 0x80, 0x9f})
+		if err != nil || !reflect.DeepEqual(edt, []byte{0x02, 0x80, 0x9f}) {
+			t.Errorf("EncodePropertyMap failure: %x, %v", edt, err)
+		}
+	})
+}
+
+func TestGetPropertyMapMalformed(t *testing.T) {
+	for _, edt := range [][]byte{{}, {0x03, 0x80, 0x81}, {0x10, 0x01}} {
+		if _, err := echonetlite.GetPropertyMap(echonetlite.Property{Epc: 0x9f, Edt: edt}); !errors.Is(err, echonetlite.ErrTruncated) {
+			t.Errorf("GetPropertyMap failure for %x: %v", edt, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package echonetlite
+
+import (
+	"encoding/binary"
+	"net"
+	"sync"
+)
+
+// ReplayTransport is a Transport which feeds recorded packets to a
+// Controller, e.g. to reproduce a bug reported with a capture in a test.
+//
+// Inbound packets and packets without a direction are received in order.
+// An inbound packet is received only after the controller has sent as many
+// datagrams as the outbound packets recorded before it, so responses are
+// replayed after the requests which caused them. Packets in a capture
+// without directions, like pcap, are received immediately; filter them by
+// Src before replaying them if needed.
+//
+// The TID of an inbound packet is rewritten to the TID of the datagram sent
+// in place of the recorded outbound packet with the same TID, so responses
+// reach the requests even if the TIDs of the controller differ from the
+// recorded ones.
+type ReplayTransport struct {
+	m       sync.Mutex
+	packets []CapturedPacket
+	sent    [][]byte
+	// matched is the number of sent datagrams which correspond to outbound
+	// packets already replayed.
+	matched int
+	// tids maps TIDs of recorded outbound packets to TIDs of sent datagrams.
+	tids    map[uint16]uint16
+	changed chan struct{}
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func NewReplayTransport(packets []CapturedPacket) *ReplayTransport {
+	return &ReplayTransport{
+		packets: packets,
+		sent:    [][]byte{},
+		tids:    map[uint16]uint16{},
+		changed: make(chan struct{}, 1),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (t *ReplayTransport) Send(addr net.UDPAddr, data []byte) error {
+	return t.record(data)
+}
+
+func (t *ReplayTransport) SendMulticast(data []byte) error {
+	return t.record(data)
+}
+
+func (t *ReplayTransport) record(data []byte) error {
+	select {
+	case <-t.closed:
+		return net.ErrClosed
+	default:
+	}
+
+	t.m.Lock()
+	t.sent = append(t.sent, append([]byte{}, data...))
+	t.m.Unlock()
+
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Sent returns datagrams sent by the controller.
+func (t *ReplayTransport) Sent() [][]byte {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return append([][]byte{}, t.sent...)
+}
+
+// Receive returns the next inbound packet once the controller has sent the
+// datagrams recorded before it. It blocks until the transport is closed
+// after all packets are replayed.
+func (t *ReplayTransport) Receive() ([]byte, net.UDPAddr, error) {
+	for {
+		t.m.Lock()
+		p, ready := t.next()
+		t.m.Unlock()
+		if ready {
+			return p.Data, p.Src, nil
+		}
+
+		select {
+		case <-t.changed:
+		case <-t.closed:
+			return nil, net.UDPAddr{}, net.ErrClosed
+		}
+	}
+}
+
+// next pops the next inbound packet if it is ready to be received.
+func (t *ReplayTransport) next() (CapturedPacket, bool) {
+	outbound := 0
+	for i, p := range t.packets {
+		if p.Direction == DirectionOutbound {
+			outbound++
+			continue
+		}
+		if t.matched+outbound > len(t.sent) {
+			return CapturedPacket{}, false
+		}
+		for j, recorded := range t.packets[:i] {
+			if tid, ok := frameTid(recorded.Data); ok {
+				if sentTid, ok := frameTid(t.sent[t.matched+j]); ok {
+					t.tids[tid] = sentTid
+				}
+			}
+		}
+		t.packets = t.packets[i+1:]
+		t.matched += outbound
+		if tid, ok := frameTid(p.Data); ok {
+			if sentTid, ok := t.tids[tid]; ok {
+				p.Data = append([]byte{}, p.Data...)
+				binary.BigEndian.PutUint16(p.Data[2:], sentTid)
+			}
+		}
+		return p, true
+	}
+	return CapturedPacket{}, false
+}
+
+// frameTid returns the TID of an ECHONET Lite frame in data.
+func frameTid(data []byte) (uint16, bool) {
+	if len(data) < 4 || data[0] != 0x10 || data[1] != 0x81 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(data[2:]), true
+}
+
+func (t *ReplayTransport) Close() error {
+	t.once.Do(func() { close(t.closed) })
+	return nil
+}
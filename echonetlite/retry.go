@@ -0,0 +1,164 @@
+package echonetlite
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// RetryPolicy configures how QueryContext and SetContext retry a request
+// whose responses are lost. The zero value disables retries.
+type RetryPolicy struct {
+	// Count is the maximum number of retries after the first attempt.
+	Count int
+	// Backoff is the delay before the first retry. It doubles on every
+	// retry. Every delay is capped at MaxBackoff if MaxBackoff is positive.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter randomizes each delay by up to the fraction of it, e.g. 0.2 for
+	// ±20%, so that controllers don't retry in lockstep.
+	Jitter float64
+	// RetryUnavailable re-queries properties which a device failed to read
+	// in a Get_SNA response. It should be enabled only if unsupported
+	// properties are excluded from queries, see Capabilities.
+	RetryUnavailable bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Count:      2,
+	Backoff:    100 * time.Millisecond,
+	MaxBackoff: 1 * time.Second,
+	Jitter:     0.2,
+}
+
+// delay returns the delay before the retry-th retry (1-origin).
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+func (p RetryPolicy) sleep(ctx context.Context, retry int) error {
+	timer := time.NewTimer(p.delay(retry))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// resend sends f again to the devices which haven't responded yet. The TID
+// is reused, so late responses to any of the attempts are collected by the
+// same receiver.
+func (q QueryBuilder) resend(f Frame, opts QueryOptions, data []receiverData) error {
+	if len(opts.Addresses) == 0 {
+		return q.controller.send(q.Address, f)
+	}
+	for _, addr := range opts.Addresses {
+		if slices.ContainsFunc(data, func(d receiverData) bool { return sameHost(d.addr, addr) }) {
+			continue
+		}
+		addr := addr
+		if err := q.controller.send(&addr, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryUnavailable re-queries properties which devices failed to read in
+// Get_SNA responses and merges the values into the responses. Since the
+// request differs from the original one, each re-query has a new TID.
+func (q QueryBuilder) retryUnavailable(ctx context.Context, f Frame, responses []QueryResponse) {
+	for retry := 1; retry <= q.Retry.Count; retry++ {
+		incomplete := slices.IndexFunc(responses, func(res QueryResponse) bool { return len(unavailableEpcs(res.Frame)) > 0 })
+		if incomplete < 0 {
+			return
+		}
+		if q.Retry.sleep(ctx, retry) != nil {
+			return
+		}
+
+		for i := incomplete; i < len(responses); i++ {
+			epcs := unavailableEpcs(responses[i].Frame)
+			if len(epcs) == 0 {
+				continue
+			}
+
+			requery := q.controller.CreateFrame()
+			requery.Edata = SpecifiedMessage{
+				Seoj:       f.Edata.Seoj,
+				Deoj:       responses[i].Frame.Edata.Seoj,
+				Esv:        ServiceTypeGet,
+				Properties: []Property{},
+			}
+			for _, epc := range epcs {
+				requery.Edata.Properties = append(requery.Edata.Properties, Property{Epc: epc, Edt: []byte{}})
+			}
+
+			sub := q
+			sub.Address = &net.UDPAddr{IP: responses[i].Addr.IP, Port: responses[i].Addr.Port, Zone: responses[i].Addr.Zone}
+			sub.Retry = RetryPolicy{}
+			retried, err := sub.QueryContext(ctx, requery, QueryOptions{})
+			if err != nil {
+				return
+			}
+			for _, res := range retried {
+				if res.Frame.Edata.Seoj == responses[i].Frame.Edata.Seoj {
+					responses[i].Frame = mergeProperties(responses[i].Frame, res.Frame)
+					break
+				}
+			}
+		}
+	}
+}
+
+// unavailableEpcs returns EPCs without a value in a Get_SNA frame.
+func unavailableEpcs(f Frame) []byte {
+	epcs := []byte{}
+	if f.Edata.Esv != ServiceTypeGetSna {
+		return epcs
+	}
+	for _, prop := range f.Edata.Properties {
+		if len(prop.Edt) == 0 {
+			epcs = append(epcs, prop.Epc)
+		}
+	}
+	return epcs
+}
+
+// mergeProperties fills properties without a value in dst with values in
+// src. dst becomes a Get_Res frame once all of the properties have a value.
+func mergeProperties(dst, src Frame) Frame {
+	properties := slices.Clone(dst.Edata.Properties)
+	for i, prop := range properties {
+		if len(prop.Edt) > 0 {
+			continue
+		}
+		for _, p := range src.Edata.Properties {
+			if p.Epc == prop.Epc && len(p.Edt) > 0 {
+				properties[i] = p
+				break
+			}
+		}
+	}
+	dst.Edata.Properties = properties
+
+	if !slices.ContainsFunc(properties, func(p Property) bool { return len(p.Edt) == 0 }) {
+		dst.Edata.Esv = ServiceTypeGetRes
+	}
+	return dst
+}
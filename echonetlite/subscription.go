@@ -0,0 +1,112 @@
+package echonetlite
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/exp/slices"
+)
+
+// SubscriptionFilter selects INF and INFC frames delivered to a subscriber.
+// Zero values match everything. A Seoj whose instance code is 0 matches all
+// instances of the class.
+type SubscriptionFilter struct {
+	Addr *net.UDPAddr
+	Seoj EOJ
+	Epcs []byte
+}
+
+func (f SubscriptionFilter) match(addr net.UDPAddr, frame Frame) bool {
+	if frame.Edata.Esv != ServiceTypeInf && frame.Edata.Esv != ServiceTypeInfC {
+		return false
+	}
+	if f.Addr != nil && !sameHost(*f.Addr, addr) {
+		return false
+	}
+	if f.Seoj != 0 && !f.Seoj.Matches(frame.Edata.Seoj) {
+		return false
+	}
+	if len(f.Epcs) > 0 {
+		return slices.ContainsFunc(frame.Edata.Properties, func(p Property) bool {
+			return slices.Contains(f.Epcs, p.Epc)
+		})
+	}
+	return true
+}
+
+type Notification struct {
+	Addr  net.UDPAddr
+	Frame Frame
+}
+
+type Subscription struct {
+	filter     SubscriptionFilter
+	handler    func(Notification)
+	collection *subscriptionCollection
+}
+
+func (s *Subscription) Unsubscribe() {
+	s.collection.Remove(s)
+}
+
+// Subscribe registers handler to be called for every INF and INFC frame
+// matching filter. Handlers are called from the listener goroutine, so they
+// must not block.
+func (c *Controller) Subscribe(filter SubscriptionFilter, handler func(Notification)) *Subscription {
+	s := &Subscription{
+		filter:     filter,
+		handler:    handler,
+		collection: &c.subscriptions,
+	}
+	c.subscriptions.Add(s)
+	return s
+}
+
+// SubscribeChannel is like Subscribe but sends notifications to ch.
+// Notifications are dropped while ch is full.
+func (c *Controller) SubscribeChannel(filter SubscriptionFilter, ch chan<- Notification) *Subscription {
+	return c.Subscribe(filter, func(n Notification) {
+		select {
+		case ch <- n:
+		default:
+			c.Logger.Debug("[SubscribeChannel] channel is full, drop a notification")
+		}
+	})
+}
+
+type subscriptionCollection struct {
+	m             sync.Mutex
+	subscriptions []*Subscription
+}
+
+func (c *subscriptionCollection) Add(s *Subscription) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.subscriptions = append(c.subscriptions, s)
+}
+
+func (c *subscriptionCollection) Remove(target *Subscription) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	newSlice := []*Subscription{}
+	for _, s := range c.subscriptions {
+		if s != target {
+			newSlice = append(newSlice, s)
+		}
+	}
+	c.subscriptions = newSlice
+}
+
+func (c *subscriptionCollection) NotifyAll(addr net.UDPAddr, f Frame) {
+	c.m.Lock()
+	subscriptions := slices.Clone(c.subscriptions)
+	c.m.Unlock()
+
+	for _, s := range subscriptions {
+		if s.filter.match(addr, f) {
+			s.handler(Notification{Addr: addr, Frame: f})
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package echonetlite
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Transport sends and receives ECHONET Lite datagrams for a Controller.
+type Transport interface {
+	// Send sends data to addr.
+	Send(addr net.UDPAddr, data []byte) error
+	// SendMulticast sends data to the ECHONET Lite multicast group.
+	SendMulticast(data []byte) error
+	// Receive blocks until a datagram arrives and returns it with its source
+	// address. It returns net.ErrClosed after the transport is closed.
+	Receive() ([]byte, net.UDPAddr, error)
+	Close() error
+}
+
+type UDPTransportConfig struct {
+	// Network is "udp4" (default), "udp6" or "udp" for dual stack.
+	Network string
+	// Interface is the network interface on which the transport joins the
+	// multicast group and sends multicast datagrams. The system default
+	// interface is used if it is nil.
+	Interface *net.Interface
+	// LocalAddress is the address to receive datagrams on. It defaults to
+	// 0.0.0.0:3610. Since multicast datagrams are only delivered to a
+	// socket bound to the wildcard address, an IP address in LocalAddress
+	// selects the interface which has the address instead of binding to it.
+	LocalAddress *net.UDPAddr
+}
+
+const (
+	// maxDatagramSize is the maximum UDP payload, so that no datagram is
+	// truncated.
+	maxDatagramSize = 65535
+	// minReadErrorDelay and maxReadErrorDelay bound the delay before
+	// reading again after an error, which doubles while errors continue.
+	minReadErrorDelay = 10 * time.Millisecond
+	maxReadErrorDelay = time.Second
+)
+
+// UDPTransport is a Transport over UDP sockets which join the ECHONET Lite
+// multicast groups.
+type UDPTransport struct {
+	conns   []multicastConn
+	packets chan packet
+	closed  chan struct{}
+	once    sync.Once
+}
+
+type multicastConn struct {
+	conn  *net.UDPConn
+	group *net.UDPAddr
+	ipv6  bool
+}
+
+type packet struct {
+	data []byte
+	addr net.UDPAddr
+}
+
+func ListenUDP(config UDPTransportConfig) (*UDPTransport, error) {
+	laddr := &net.UDPAddr{Port: DefaultPort}
+	if config.LocalAddress != nil {
+		laddr = config.LocalAddress
+	}
+
+	ifi := config.Interface
+	if ifi == nil && laddr.IP != nil && !laddr.IP.IsUnspecified() {
+		var err error
+		ifi, err = interfaceByIP(laddr.IP)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	networks, err := udpNetworks(config.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &UDPTransport{
+		conns:   []multicastConn{},
+		packets: make(chan packet),
+		closed:  make(chan struct{}),
+	}
+	for _, network := range networks {
+		group, err := multicastAddress(network, ifi)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+
+		conn, err := net.ListenMulticastUDP(network, ifi, &net.UDPAddr{IP: group.IP, Port: laddr.Port})
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+		t.conns = append(t.conns, multicastConn{conn: conn, group: group, ipv6: network == "udp6"})
+	}
+
+	for _, conn := range t.conns {
+		go t.read(conn.conn)
+	}
+	return t, nil
+}
+
+func udpNetworks(network string) ([]string, error) {
+	switch network {
+	case "", "udp4":
+		return []string{"udp4"}, nil
+	case "udp6":
+		return []string{"udp6"}, nil
+	case "udp":
+		return []string{"udp4", "udp6"}, nil
+	default:
+		return nil, ErrUnsupportedNetwork
+	}
+}
+
+// multicastAddress returns the ECHONET Lite multicast group for network.
+// The IPv6 group is link-local, so it is scoped to ifi if specified.
+func multicastAddress(network string, ifi *net.Interface) (*net.UDPAddr, error) {
+	if network == "udp6" {
+		addr, err := net.ResolveUDPAddr(network, BroadcastAddressIPv6)
+		if err != nil {
+			return nil, err
+		}
+		if ifi != nil {
+			addr.Zone = ifi.Name
+		}
+		return addr, nil
+	}
+	return net.ResolveUDPAddr(network, BroadcastAddress)
+}
+
+func interfaceByIP(ip net.IP) (*net.Interface, error) {
+	ifis, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, ifi := range ifis {
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(ip) {
+				return &ifi, nil
+			}
+		}
+	}
+	return nil, ErrNoSuchInterface
+}
+
+func (t *UDPTransport) read(conn *net.UDPConn) {
+	buf := make([]byte, maxDatagramSize)
+	delay := time.Duration(0)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			// back off from an error which persists, e.g. of a removed
+			// interface, instead of spinning
+			delay = min(max(2*delay, minReadErrorDelay), maxReadErrorDelay)
+			select {
+			case <-t.closed:
+				return
+			case <-time.After(delay):
+				continue
+			}
+		}
+		delay = 0
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		select {
+		case t.packets <- packet{data: data, addr: *addr}:
+		case <-t.closed:
+			return
+		}
+	}
+}
+
+func (t *UDPTransport) Send(addr net.UDPAddr, data []byte) error {
+	ipv6 := addr.IP.To4() == nil
+	for _, conn := range t.conns {
+		if conn.ipv6 == ipv6 {
+			_, err := conn.conn.WriteToUDP(data, &addr)
+			return err
+		}
+	}
+	return ErrUnsupportedNetwork
+}
+
+func (t *UDPTransport) SendMulticast(data []byte) error {
+	for _, conn := range t.conns {
+		if _, err := conn.conn.WriteToUDP(data, conn.group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *UDPTransport) Receive() ([]byte, net.UDPAddr, error) {
+	select {
+	case p := <-t.packets:
+		return p.data, p.addr, nil
+	case <-t.closed:
+		return nil, net.UDPAddr{}, net.ErrClosed
+	}
+}
+
+func (t *UDPTransport) Close() error {
+	t.once.Do(func() {
+		close(t.closed)
+		for _, conn := range t.conns {
+			conn.conn.Close()
+		}
+	})
+	return nil
+}
@@ -0,0 +1,75 @@
+package echonetlite_test
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+func TestListenUDP(t *testing.T) {
+	t.Run("Loopback", func(t *testing.T) {
+		for _, network := range []string{"udp4", "udp6"} {
+			transport, err := echonetlite.ListenUDP(echonetlite.UDPTransportConfig{
+				Network:      network,
+				LocalAddress: &net.UDPAddr{Port: 3620},
+			})
+			if err != nil {
+				t.Errorf("ListenUDP failure for %s: %v", network, err)
+				continue
+			}
+			defer transport.Close()
+
+			loopback := net.IPv4(127, 0, 0, 1)
+			if network == "udp6" {
+				loopback = net.IPv6loopback
+			}
+			peer, err := net.ListenUDP(network, &net.UDPAddr{IP: loopback})
+			if err != nil {
+				t.Fatalf("ListenUDP failure: %v", err)
+			}
+			defer peer.Close()
+
+			if err := transport.Send(*peer.LocalAddr().(*net.UDPAddr), []byte{0x01}); err != nil {
+				t.Fatalf("Send failure: %v", err)
+			}
+			// datagrams are sent from the listening socket, so replies reach it
+			buf := make([]byte, 16)
+			peer.SetReadDeadline(time.Now().Add(5 * time.Second))
+			_, addr, err := peer.ReadFromUDP(buf)
+			if err != nil || addr.Port != 3620 {
+				t.Fatalf("Send failure: from %v, %v", addr, err)
+			}
+			peer.WriteToUDP([]byte{0x02}, addr)
+			data, from, err := transport.Receive()
+			if err != nil || !reflect.DeepEqual(data, []byte{0x02}) || !from.IP.Equal(loopback) {
+				t.Errorf("Receive failure: %x from %v, %v", data, from, err)
+			}
+			// a datagram larger than a typical frame isn't truncated
+			large := make([]byte, 4096)
+			large[len(large)-1] = 0x03
+			peer.WriteToUDP(large, addr)
+			if data, _, err := transport.Receive(); err != nil || !reflect.DeepEqual(data, large) {
+				t.Errorf("Receive failure: %d bytes, %v", len(data), err)
+			}
+		}
+	})
+
+	t.Run("NoSuchInterface", func(t *testing.T) {
+		// no interface has an address of TEST-NET-1 reserved for documentation
+		_, err := echonetlite.ListenUDP(echonetlite.UDPTransportConfig{
+			LocalAddress: &net.UDPAddr{IP: net.IPv4(192, 0, 2, 254), Port: echonetlite.DefaultPort},
+		})
+		if err != echonetlite.ErrNoSuchInterface {
+			t.Errorf("ListenUDP failure: %v", err)
+		}
+	})
+
+	t.Run("UnsupportedNetwork", func(t *testing.T) {
+		if _, err := echonetlite.ListenUDP(echonetlite.UDPTransportConfig{Network: "ipx"}); err != echonetlite.ErrUnsupportedNetwork {
+			t.Errorf("ListenUDP failure: %v", err)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package smartmeter
+
+// CumulativeEnergy requests the cumulative energy bought from the grid with
+// the unit and the coefficient to convert it to kWh.
+func (r QueryRequest) CumulativeEnergy() QueryRequest {
+	return r.AddEpc(EpcNormalDirectionCumulativeElectricEnergy).AddEpc(EpcCumulativeEnergyUnit).AddEpc(EpcCoefficient)
+}
+
+// ReverseCumulativeEnergy requests the cumulative energy sold to the grid
+// with the unit and the coefficient to convert it to kWh.
+func (r QueryRequest) ReverseCumulativeEnergy() QueryRequest {
+	return r.AddEpc(EpcReverseDirectionCumulativeElectricEnergy).AddEpc(EpcCumulativeEnergyUnit).AddEpc(EpcCoefficient)
+}
+
+func (r QueryRequest) HistoricalCumulativeEnergy() QueryRequest {
+	return r.AddEpc(EpcHistoricalCumulativeEnergy).AddEpc(EpcCumulativeEnergyUnit).AddEpc(EpcCoefficient)
+}
+
+func (r QueryRequest) ReverseHistoricalCumulativeEnergy() QueryRequest {
+	return r.AddEpc(EpcReverseHistoricalCumulativeEnergy).AddEpc(EpcCumulativeEnergyUnit).AddEpc(EpcCoefficient)
+}
+
+func (r QueryRequest) FixedTimeCumulativeEnergy() QueryRequest {
+	return r.AddEpc(EpcFixedTimeCumulativeEnergy).AddEpc(EpcCumulativeEnergyUnit).AddEpc(EpcCoefficient)
+}
+
+func (r QueryRequest) ReverseFixedTimeCumulativeEnergy() QueryRequest {
+	return r.AddEpc(EpcReverseFixedTimeCumulativeEnergy).AddEpc(EpcCumulativeEnergyUnit).AddEpc(EpcCoefficient)
+}
+
+func (r QueryRequest) InstantaneousCurrents() QueryRequest {
+	return r.AddEpc(EpcInstantaneousCurrents)
+}
@@ -0,0 +1,209 @@
+package smartmeter
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+const (
+	maxCumulativeEnergy = 99999999
+	noData              = 0xfffffffe
+)
+
+// cumulativeEnergyUnits is kWh of 1 in cumulative energy for each EDT of
+// EpcCumulativeEnergyUnit.
+var cumulativeEnergyUnits = map[byte]float64{
+	0x00: 1,
+	0x01: 0.1,
+	0x02: 0.01,
+	0x03: 0.001,
+	0x04: 0.0001,
+	0x0a: 10,
+	0x0b: 100,
+	0x0c: 1000,
+	0x0d: 10000,
+}
+
+// Currents is instantaneous currents of the R phase and the T phase.
+type Currents struct {
+	R float64
+	T float64
+	// SinglePhase is true for a single-phase two-wire meter, which measures
+	// only R.
+	SinglePhase bool
+}
+
+// HistoricalEnergy is the cumulative energy at every 30 minutes of a day.
+type HistoricalEnergy struct {
+	// Day is the number of days before today, which is 0.
+	Day int
+	// Values are the cumulative energy in kWh at 00:00, 00:30, ... and
+	// 23:30. Values which the meter has no data for are NaN.
+	Values []float64
+}
+
+// Coefficient returns the coefficient of cumulative energy, which is 1 if
+// the meter does not support it or responds to it with Get_SNA.
+func (q QueryResponse) Coefficient() (int, error) {
+	data, err := q.FixedEdt(EpcCoefficient, 4)
+	if errors.Is(err, echonetlite.ErrPropertyNotSupported) || errors.Is(err, echonetlite.ErrPropertyNotAvailable) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	value := binary.BigEndian.Uint32(data)
+	if value < 1 || value > 999999 {
+		return 0, ErrUnsupportedValue
+	}
+	return int(value), nil
+}
+
+// CumulativeEnergyUnit returns kWh of 1 in cumulative energy.
+func (q QueryResponse) CumulativeEnergyUnit() (float64, error) {
+	data, err := q.FixedEdt(EpcCumulativeEnergyUnit, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	unit, ok := cumulativeEnergyUnits[data[0]]
+	if !ok {
+		return 0, ErrUnsupportedValue
+	}
+	return unit, nil
+}
+
+// scale returns kWh of 1 in cumulative energy multiplied by the coefficient.
+func (q QueryResponse) scale() (float64, error) {
+	unit, err := q.CumulativeEnergyUnit()
+	if err != nil {
+		return 0, err
+	}
+	coefficient, err := q.Coefficient()
+	if err != nil {
+		return 0, err
+	}
+	return unit * float64(coefficient), nil
+}
+
+// cumulativeEnergy converts value of the cumulative energy to kWh.
+func (q QueryResponse) cumulativeEnergy(value int, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	scale, err := q.scale()
+	if err != nil {
+		return 0, err
+	}
+	return float64(value) * scale, nil
+}
+
+// CumulativeEnergy returns the cumulative energy bought from the grid in
+// kWh.
+func (q QueryResponse) CumulativeEnergy() (float64, error) {
+	return q.cumulativeEnergy(q.NormalDirectionCumulativeElectricEnergy())
+}
+
+// ReverseCumulativeEnergy returns the cumulative energy sold to the grid in
+// kWh.
+func (q QueryResponse) ReverseCumulativeEnergy() (float64, error) {
+	return q.cumulativeEnergy(q.ReverseDirectionCumulativeElectricEnergy())
+}
+
+func (q QueryResponse) historicalCumulativeEnergy(epc byte) (HistoricalEnergy, error) {
+	data, err := q.FixedEdt(epc, 2+48*4)
+	if err != nil {
+		return HistoricalEnergy{}, err
+	}
+	scale, err := q.scale()
+	if err != nil {
+		return HistoricalEnergy{}, err
+	}
+
+	h := HistoricalEnergy{Day: int(binary.BigEndian.Uint16(data)), Values: make([]float64, 48)}
+	for i := range h.Values {
+		value := binary.BigEndian.Uint32(data[2+i*4:])
+		switch {
+		case value == noData:
+			h.Values[i] = math.NaN()
+		case value > maxCumulativeEnergy:
+			return HistoricalEnergy{}, ErrUnsupportedValue
+		default:
+			h.Values[i] = float64(value) * scale
+		}
+	}
+	return h, nil
+}
+
+// HistoricalCumulativeEnergy returns the cumulative energy bought from the
+// grid at every 30 minutes of a day.
+func (q QueryResponse) HistoricalCumulativeEnergy() (HistoricalEnergy, error) {
+	return q.historicalCumulativeEnergy(EpcHistoricalCumulativeEnergy)
+}
+
+// ReverseHistoricalCumulativeEnergy returns the cumulative energy sold to the
+// grid at every 30 minutes of a day.
+func (q QueryResponse) ReverseHistoricalCumulativeEnergy() (HistoricalEnergy, error) {
+	return q.historicalCumulativeEnergy(EpcReverseHistoricalCumulativeEnergy)
+}
+
+func (q QueryResponse) fixedTimeCumulativeEnergy(epc byte) (time.Time, float64, error) {
+	data, err := q.FixedEdt(epc, 11)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	scale, err := q.scale()
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+
+	year, month, day := int(binary.BigEndian.Uint16(data)), time.Month(data[2]), int(data[3])
+	t := time.Date(year, month, day, int(data[4]), int(data[5]), int(data[6]), 0, time.Local)
+	if t.Month() != month || t.Day() != day || data[4] > 23 || data[5] > 59 || data[6] > 59 {
+		return time.Time{}, 0, ErrUnsupportedValue
+	}
+	value := binary.BigEndian.Uint32(data[7:])
+	if value > maxCumulativeEnergy {
+		return time.Time{}, 0, ErrUnsupportedValue
+	}
+	return t, float64(value) * scale, nil
+}
+
+// FixedTimeCumulativeEnergy returns the cumulative energy bought from the
+// grid in kWh at the last 30 minutes boundary, and the time. The time is in
+// time.Local since the meter has no time zone.
+func (q QueryResponse) FixedTimeCumulativeEnergy() (time.Time, float64, error) {
+	return q.fixedTimeCumulativeEnergy(EpcFixedTimeCumulativeEnergy)
+}
+
+// ReverseFixedTimeCumulativeEnergy returns the cumulative energy sold to the
+// grid in kWh at the last 30 minutes boundary, and the time.
+func (q QueryResponse) ReverseFixedTimeCumulativeEnergy() (time.Time, float64, error) {
+	return q.fixedTimeCumulativeEnergy(EpcReverseFixedTimeCumulativeEnergy)
+}
+
+// InstantaneousCurrents returns the instantaneous currents in A.
+func (q QueryResponse) InstantaneousCurrents() (Currents, error) {
+	data, err := q.FixedEdt(EpcInstantaneousCurrents, 4)
+	if err != nil {
+		return Currents{}, err
+	}
+
+	r := int16(binary.BigEndian.Uint16(data))
+	t := int16(binary.BigEndian.Uint16(data[2:]))
+	if r > 32765 || r < -32767 {
+		return Currents{}, ErrUnsupportedValue
+	}
+	if t == 0x7ffe {
+		return Currents{R: float64(r) / 10, SinglePhase: true}, nil
+	}
+	if t > 32765 || t < -32767 {
+		return Currents{}, ErrUnsupportedValue
+	}
+	return Currents{R: float64(r) / 10, T: float64(t) / 10}, nil
+}
@@ -0,0 +1,49 @@
+// Package smartmeter queries low-voltage smart electric energy meters
+// (0x0288) through ECHONET Lite, e.g. via a HEMS gateway to the B-route.
+package smartmeter
+
+import (
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+const (
+	// ObjectSmartMeter is the first instance of low-voltage smart electric
+	// energy meters. See QueryRequest.SetInstance for other instances.
+	ObjectSmartMeter echonetlite.EOJ = 0x028801
+)
+
+//go:generate go run ../cmd/echonetgen -class 0x0288 -package smartmeter -request -skip d3,e1,e2,e4,e8,ea,eb -output meter_gen.go
+
+const (
+	EpcCoefficient                       byte = 0xd3
+	EpcCumulativeEnergyUnit              byte = 0xe1
+	EpcHistoricalCumulativeEnergy        byte = 0xe2
+	EpcReverseHistoricalCumulativeEnergy byte = 0xe4
+	EpcInstantaneousCurrents             byte = 0xe8
+	EpcFixedTimeCumulativeEnergy         byte = 0xea
+	EpcReverseFixedTimeCumulativeEnergy  byte = 0xeb
+)
+
+const (
+	// EchonetLiteTimeout is longer than daikin.EchonetLiteTimeout since
+	// gateways relay requests to meters over the slow B-route.
+	EchonetLiteTimeout = 5 * time.Second
+)
+
+type SmartMeter struct {
+	controller *echonetlite.Controller
+}
+
+func NewSmartMeter(c *echonetlite.Controller) SmartMeter {
+	return SmartMeter{
+		controller: c,
+	}
+}
+
+func (m *SmartMeter) Request() QueryRequest {
+	return QueryRequest{
+		req: echonetlite.NewPropertyRequest(m.controller, ObjectSmartMeter).WithTimeout(EchonetLiteTimeout),
+	}
+}
@@ -0,0 +1,187 @@
+package smartmeter_test
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/echonettest"
+	"github.com/int2xx9/daikin-airconditioner/smartmeter"
+)
+
+var (
+	controllerAddr = net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 3610}
+	meterAddr      = net.UDPAddr{IP: net.IPv4(192, 0, 2, 21), Port: 3610}
+)
+
+func historicalData() []byte {
+	data := []byte{0x00, 0x01}
+	for i := 0; i < 48; i++ {
+		value := []byte{0x00, 0x00, 0x30, byte(0x39 + i)}
+		if i == 47 {
+			value = []byte{0xff, 0xff, 0xff, 0xfe}
+		}
+		data = append(data, value...)
+	}
+	return data
+}
+
+func startMeter(t *testing.T, network *echonetlite.MemoryNetwork, addr net.UDPAddr, properties map[byte][]byte) {
+	echonettest.StartDevice(t, network, addr, &echonetlite.LocalObject{
+		Eoj:        smartmeter.ObjectSmartMeter,
+		Properties: properties,
+	})
+}
+
+func TestQuery(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	startMeter(t, network, meterAddr, map[byte][]byte{
+		echonetlite.EpcIdentificationNumber:                    {0xfe, 0x00, 0x00, 0x16, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c, 0x0d},
+		smartmeter.EpcCoefficient:                              {0x00, 0x00, 0x00, 0x02},
+		smartmeter.EpcNormalDirectionCumulativeElectricEnergy:  {0x00, 0x00, 0x30, 0x39},
+		smartmeter.EpcCumulativeEnergyUnit:                     {0x01},
+		smartmeter.EpcReverseDirectionCumulativeElectricEnergy: {0x00, 0x00, 0x00, 0x64},
+		smartmeter.EpcHistoricalCumulativeEnergy:               historicalData(),
+		smartmeter.EpcReverseHistoricalCumulativeEnergy:        historicalData(),
+		smartmeter.EpcInstantaneousElectricPower:               {0xff, 0xff, 0xfe, 0x0c},
+		smartmeter.EpcInstantaneousCurrents:                    {0x00, 0x7b, 0x7f, 0xfe},
+		smartmeter.EpcFixedTimeCumulativeEnergy:                {0x07, 0xe7, 0x0a, 0x11, 0x0c, 0x00, 0x00, 0x00, 0x00, 0x30, 0x39},
+		smartmeter.EpcReverseFixedTimeCumulativeEnergy:         {0x07, 0xe7, 0x0a, 0x11, 0x0c, 0x1e, 0x00, 0x00, 0x00, 0x00, 0x64},
+	})
+	m := smartmeter.NewSmartMeter(c)
+
+	resps, err := m.Request().
+		IdentificationNumber().
+		CumulativeEnergy().
+		ReverseCumulativeEnergy().
+		HistoricalCumulativeEnergy().
+		ReverseHistoricalCumulativeEnergy().
+		FixedTimeCumulativeEnergy().
+		ReverseFixedTimeCumulativeEnergy().
+		InstantaneousElectricPower().
+		InstantaneousCurrents().
+		SetAddress(meterAddr).
+		Query()
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	resp := resps[0]
+
+	if v, err := resp.IdentificationNumber(); err != nil || v[15] != 0x0d {
+		t.Errorf("IdentificationNumber failure: %v, %v", v, err)
+	}
+	if v, err := resp.CumulativeEnergy(); err != nil || math.Abs(v-2469.0) > 1e-9 {
+		t.Errorf("CumulativeEnergy failure: %v, %v", v, err)
+	}
+	if v, err := resp.ReverseCumulativeEnergy(); err != nil || math.Abs(v-20.0) > 1e-9 {
+		t.Errorf("ReverseCumulativeEnergy failure: %v, %v", v, err)
+	}
+	for _, get := range []func() (smartmeter.HistoricalEnergy, error){resp.HistoricalCumulativeEnergy, resp.ReverseHistoricalCumulativeEnergy} {
+		h, err := get()
+		if err != nil || h.Day != 1 || len(h.Values) != 48 || math.Abs(h.Values[1]-2469.2) > 1e-9 || !math.IsNaN(h.Values[47]) {
+			t.Errorf("HistoricalCumulativeEnergy failure: %v, %v", h, err)
+		}
+	}
+	if at, v, err := resp.FixedTimeCumulativeEnergy(); err != nil || !at.Equal(time.Date(2023, 10, 17, 12, 0, 0, 0, time.Local)) || math.Abs(v-2469.0) > 1e-9 {
+		t.Errorf("FixedTimeCumulativeEnergy failure: %v, %v, %v", at, v, err)
+	}
+	if at, v, err := resp.ReverseFixedTimeCumulativeEnergy(); err != nil || !at.Equal(time.Date(2023, 10, 17, 12, 30, 0, 0, time.Local)) || math.Abs(v-20.0) > 1e-9 {
+		t.Errorf("ReverseFixedTimeCumulativeEnergy failure: %v, %v, %v", at, v, err)
+	}
+	if v, err := resp.InstantaneousElectricPower(); err != nil || v != -500 {
+		t.Errorf("InstantaneousPower failure: %v, %v", v, err)
+	}
+	if v, err := resp.InstantaneousCurrents(); err != nil || v != (smartmeter.Currents{R: 12.3, SinglePhase: true}) {
+		t.Errorf("InstantaneousCurrents failure: %v, %v", v, err)
+	}
+}
+
+func TestQueryWithoutCoefficient(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	startMeter(t, network, meterAddr, map[byte][]byte{
+		smartmeter.EpcNormalDirectionCumulativeElectricEnergy: {0x00, 0x00, 0x30, 0x39},
+		smartmeter.EpcCumulativeEnergyUnit:                    {0x0a},
+		smartmeter.EpcInstantaneousElectricPower:              {0x7f, 0xff, 0xff, 0xfe},
+	})
+	m := smartmeter.NewSmartMeter(c)
+
+	resps, err := m.Request().CumulativeEnergy().InstantaneousElectricPower().InstantaneousCurrents().SetAddress(meterAddr).Query()
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	if v, err := resps[0].CumulativeEnergy(); err != nil || v != 123450 {
+		t.Errorf("CumulativeEnergy failure: %v, %v", v, err)
+	}
+	if _, err := resps[0].InstantaneousElectricPower(); err != smartmeter.ErrUnsupportedValue {
+		t.Errorf("InstantaneousPower failure: %v", err)
+	}
+	if _, err := resps[0].InstantaneousCurrents(); !errors.Is(err, echonetlite.ErrPropertyNotSupported) {
+		t.Errorf("InstantaneousCurrents failure: %v", err)
+	}
+}
+
+// TestQueryCoefficientNotAvailable tests a meter which lists the coefficient
+// in its Get property map but responds to it with Get_SNA.
+func TestQueryCoefficientNotAvailable(t *testing.T) {
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	meter := network.Listen(meterAddr)
+	defer meter.Close()
+
+	getMap, _ := echonetlite.EncodePropertyMap([]byte{smartmeter.EpcCoefficient, smartmeter.EpcNormalDirectionCumulativeElectricEnergy, smartmeter.EpcCumulativeEnergyUnit})
+	emptyMap, _ := echonetlite.EncodePropertyMap([]byte{})
+	properties := map[byte][]byte{
+		echonetlite.EpcAnnouncePropertyMap:                    emptyMap,
+		echonetlite.EpcSetPropertyMap:                         emptyMap,
+		echonetlite.EpcGetPropertyMap:                         getMap,
+		smartmeter.EpcNormalDirectionCumulativeElectricEnergy: {0x00, 0x00, 0x30, 0x39},
+		smartmeter.EpcCumulativeEnergyUnit:                    {0x01},
+	}
+	go func() {
+		for {
+			data, addr, err := meter.Receive()
+			if err != nil {
+				return
+			}
+			req, err := echonetlite.DeserializeFrame(data)
+			if err != nil {
+				continue
+			}
+			res := req
+			res.Edata = echonetlite.SpecifiedMessage{
+				Seoj:       req.Edata.Deoj,
+				Deoj:       req.Edata.Seoj,
+				Esv:        echonetlite.ServiceTypeGetRes,
+				Properties: []echonetlite.Property{},
+			}
+			for _, prop := range req.Edata.Properties {
+				edt, ok := properties[prop.Epc]
+				if !ok {
+					res.Edata.Esv = echonetlite.ServiceTypeGetSna
+					edt = []byte{}
+				}
+				res.Edata.Properties = append(res.Edata.Properties, echonetlite.Property{Epc: prop.Epc, Edt: edt})
+			}
+			if data, err := res.Serialize(); err == nil {
+				meter.Send(addr, data)
+			}
+		}
+	}()
+	m := smartmeter.NewSmartMeter(c)
+
+	resps, err := m.Request().CumulativeEnergy().SetAddress(meterAddr).Query()
+	if err != nil || len(resps) != 1 {
+		t.Fatalf("Query failure: %v, %v", resps, err)
+	}
+	if err := resps[0].Err(smartmeter.EpcCoefficient); !errors.Is(err, echonetlite.ErrPropertyNotAvailable) {
+		t.Errorf("Err failure: %v", err)
+	}
+	if v, err := resps[0].CumulativeEnergy(); err != nil || math.Abs(v-1234.5) > 1e-9 {
+		t.Errorf("CumulativeEnergy failure: %v, %v", v, err)
+	}
+}
@@ -0,0 +1,37 @@
+// Package solar queries household solar power generation (0x0279) through
+// ECHONET Lite, e.g. power conditioners of PV systems.
+package solar
+
+import (
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+const (
+	// ObjectSolar is the first instance of household solar power
+	// generation. See QueryRequest.SetInstance for other instances.
+	ObjectSolar echonetlite.EOJ = 0x027901
+)
+
+//go:generate go run ../cmd/echonetgen -class 0x0279 -package solar -request -output solar_gen.go
+
+const (
+	EchonetLiteTimeout = 1 * time.Second
+)
+
+type Solar struct {
+	controller *echonetlite.Controller
+}
+
+func NewSolar(c *echonetlite.Controller) Solar {
+	return Solar{
+		controller: c,
+	}
+}
+
+func (s *Solar) Request() QueryRequest {
+	return QueryRequest{
+		req: echonetlite.NewPropertyRequest(s.controller, ObjectSolar).WithTimeout(EchonetLiteTimeout),
+	}
+}
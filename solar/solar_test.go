@@ -0,0 +1,100 @@
+package solar_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/echonettest"
+	"github.com/int2xx9/daikin-airconditioner/solar"
+)
+
+var (
+	controllerAddr = net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 3610}
+	solarAddr      = net.UDPAddr{IP: net.IPv4(192, 0, 2, 31), Port: 3610}
+)
+
+// query queries epc of a device which responds with edt, or Get_SNA if edt
+// is nil.
+func query(t *testing.T, epc byte, edt []byte) solar.QueryResponse {
+	t.Helper()
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	properties := map[byte][]byte{
+		echonetlite.EpcAnnouncePropertyMap: {0x00},
+		echonetlite.EpcSetPropertyMap:      {0x00},
+		echonetlite.EpcGetPropertyMap:      {0x01, epc},
+	}
+	if edt != nil {
+		properties[epc] = edt
+	}
+	echonettest.ServeGet(t, network, solarAddr, properties)
+
+	s := solar.NewSolar(c)
+	resps, err := s.Request().AddEpc(epc).SetAddress(solarAddr).Query()
+	if err != nil {
+		t.Fatalf("Query failure: %v", err)
+	}
+	if len(resps) != 1 {
+		t.Fatalf("unexpected number of responses: %v", len(resps))
+	}
+	return resps[0]
+}
+
+func TestInstantaneousElectricPowerGeneration(t *testing.T) {
+	tests := []struct {
+		name    string
+		edt     []byte
+		want    int
+		wantErr error
+	}{
+		{"generating", []byte{0x05, 0x8c}, 1420, nil},
+		{"night", []byte{0x00, 0x00}, 0, nil},
+		{"maximum", []byte{0xff, 0xfd}, 65533, nil},
+		{"overflow", []byte{0xff, 0xfe}, 0, solar.ErrUnsupportedValue},
+		{"underflow", []byte{0xff, 0xff}, 0, solar.ErrUnsupportedValue},
+		{"short", []byte{0x05}, 0, solar.ErrUnexpectedValue},
+		{"not available", nil, 0, solar.ErrPropertyNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := query(t, solar.EpcInstantaneousElectricPowerGeneration, tt.edt)
+			got, err := res.InstantaneousElectricPowerGeneration()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value: %v", got)
+			}
+		})
+	}
+}
+
+func TestCumulativeElectricEnergyOfGeneration(t *testing.T) {
+	tests := []struct {
+		name    string
+		edt     []byte
+		want    float64
+		wantErr error
+	}{
+		{"generated", []byte{0x00, 0x2e, 0x9f, 0x3c}, 3055.42, nil},
+		{"maximum", []byte{0x3b, 0x9a, 0xc9, 0xff}, 999999.999, nil},
+		{"out of range", []byte{0x3b, 0x9a, 0xca, 0x00}, 0, solar.ErrUnsupportedValue},
+		{"overflow", []byte{0xff, 0xff, 0xff, 0xfe}, 0, solar.ErrUnsupportedValue},
+		{"long", []byte{0x00, 0x00, 0x2e, 0x9f, 0x3c}, 0, solar.ErrUnexpectedValue},
+		{"not available", nil, 0, solar.ErrPropertyNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := query(t, solar.EpcCumulativeElectricEnergyOfGeneration, tt.edt)
+			got, err := res.CumulativeElectricEnergyOfGeneration()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value: %v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+// Package storagebattery queries storage batteries (0x027D) through ECHONET
+// Lite.
+package storagebattery
+
+import (
+	"time"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+)
+
+const (
+	// ObjectStorageBattery is the first instance of storage batteries. See
+	// QueryRequest.SetInstance for other instances.
+	ObjectStorageBattery echonetlite.EOJ = 0x027d01
+)
+
+//go:generate go run ../cmd/echonetgen -class 0x027D -package storagebattery -request -output battery_gen.go
+
+const (
+	EchonetLiteTimeout = 1 * time.Second
+)
+
+type StorageBattery struct {
+	controller *echonetlite.Controller
+}
+
+func NewStorageBattery(c *echonetlite.Controller) StorageBattery {
+	return StorageBattery{
+		controller: c,
+	}
+}
+
+func (b *StorageBattery) Request() QueryRequest {
+	return QueryRequest{
+		req: echonetlite.NewPropertyRequest(b.controller, ObjectStorageBattery).WithTimeout(EchonetLiteTimeout),
+	}
+}
@@ -0,0 +1,152 @@
+package storagebattery_test
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/int2xx9/daikin-airconditioner/echonetlite"
+	"github.com/int2xx9/daikin-airconditioner/echonetlite/echonettest"
+	"github.com/int2xx9/daikin-airconditioner/storagebattery"
+)
+
+var (
+	controllerAddr = net.UDPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 3610}
+	batteryAddr    = net.UDPAddr{IP: net.IPv4(192, 0, 2, 41), Port: 3610}
+)
+
+// query queries epc of a device which responds with edt, or Get_SNA if edt
+// is nil.
+func query(t *testing.T, epc byte, edt []byte) storagebattery.QueryResponse {
+	t.Helper()
+	network := echonetlite.NewMemoryNetwork()
+	c := echonettest.StartController(t, network, controllerAddr)
+	properties := map[byte][]byte{
+		echonetlite.EpcAnnouncePropertyMap: {0x00},
+		echonetlite.EpcSetPropertyMap:      {0x00},
+		echonetlite.EpcGetPropertyMap:      {0x01, epc},
+	}
+	if edt != nil {
+		properties[epc] = edt
+	}
+	echonettest.ServeGet(t, network, batteryAddr, properties)
+
+	b := storagebattery.NewStorageBattery(c)
+	resps, err := b.Request().AddEpc(epc).SetAddress(batteryAddr).Query()
+	if err != nil {
+		t.Fatalf("Query failure: %v", err)
+	}
+	if len(resps) != 1 {
+		t.Fatalf("unexpected number of responses: %v", len(resps))
+	}
+	return resps[0]
+}
+
+func TestWorkingOperationStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		edt     []byte
+		want    storagebattery.WorkingOperationStatus
+		wantErr error
+	}{
+		{"discharging", []byte{0x43}, storagebattery.WorkingOperationStatusDischarging, nil},
+		{"standby", []byte{0x44}, storagebattery.WorkingOperationStatusStandby, nil},
+		{"undefined", []byte{0x47}, 0, storagebattery.ErrUnsupportedValue},
+		{"empty", []byte{}, 0, storagebattery.ErrUnexpectedValue},
+		{"not available", nil, 0, storagebattery.ErrPropertyNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := query(t, storagebattery.EpcWorkingOperationStatus, tt.edt)
+			got, err := res.WorkingOperationStatus()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value: %v", got)
+			}
+		})
+	}
+}
+
+func TestInstantaneousChargingDischargingElectricPower(t *testing.T) {
+	tests := []struct {
+		name    string
+		edt     []byte
+		want    int
+		wantErr error
+	}{
+		{"charging", []byte{0x00, 0x00, 0x05, 0xdc}, 1500, nil},
+		{"discharging", []byte{0xff, 0xff, 0xfc, 0xcc}, -820, nil},
+		{"minimum", []byte{0xc4, 0x65, 0x36, 0x01}, -999999999, nil},
+		{"out of range", []byte{0x3b, 0x9a, 0xca, 0x00}, 0, storagebattery.ErrUnsupportedValue},
+		{"overflow", []byte{0x7f, 0xff, 0xff, 0xff}, 0, storagebattery.ErrUnsupportedValue},
+		{"underflow", []byte{0x80, 0x00, 0x00, 0x00}, 0, storagebattery.ErrUnsupportedValue},
+		{"short", []byte{0x05, 0xdc}, 0, storagebattery.ErrUnexpectedValue},
+		{"not available", nil, 0, storagebattery.ErrPropertyNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := query(t, storagebattery.EpcInstantaneousChargingDischargingElectricPower, tt.edt)
+			got, err := res.InstantaneousChargingDischargingElectricPower()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value: %v", got)
+			}
+		})
+	}
+}
+
+func TestRemainingCapacity3(t *testing.T) {
+	tests := []struct {
+		name    string
+		edt     []byte
+		want    int
+		wantErr error
+	}{
+		{"partial", []byte{0x4b}, 75, nil},
+		{"full", []byte{0x64}, 100, nil},
+		{"out of range", []byte{0x65}, 0, storagebattery.ErrUnsupportedValue},
+		{"not available", nil, 0, storagebattery.ErrPropertyNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := query(t, storagebattery.EpcRemainingCapacity3, tt.edt)
+			got, err := res.RemainingCapacity3()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value: %v", got)
+			}
+		})
+	}
+}
+
+func TestBatteryStateOfHealth(t *testing.T) {
+	tests := []struct {
+		name    string
+		edt     []byte
+		want    int
+		wantErr error
+	}{
+		{"degraded", []byte{0x5f}, 95, nil},
+		{"out of range", []byte{0xff}, 0, storagebattery.ErrUnsupportedValue},
+		{"long", []byte{0x00, 0x5f}, 0, storagebattery.ErrUnexpectedValue},
+		{"not available", nil, 0, storagebattery.ErrPropertyNotAvailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := query(t, storagebattery.EpcBatteryStateOfHealth, tt.edt)
+			got, err := res.BatteryStateOfHealth()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("unexpected value: %v", got)
+			}
+		})
+	}
+}